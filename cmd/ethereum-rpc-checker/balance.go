@@ -0,0 +1,126 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "math/big"
+    "regexp"
+    "strings"
+
+    "github.com/ethereum/go-ethereum/rpc"
+)
+
+// balanceMethod reads the balances of an endpoint's watched accounts.
+const balanceMethod = "eth_getBalance"
+
+// Balance watches the balance of an account, e.g. a hot wallet paying for gas.
+type Balance struct {
+    Address string `yaml:"address"`
+    // Block is the block tag or hex number the balance is read at, latest by default
+    Block string `yaml:"block,omitempty"`
+    // MinWei is the balance below which the account is low, as a decimal or hex quantity
+    MinWei string `yaml:"min_wei,omitempty"`
+    // UnhealthyWhenLow marks the endpoint unhealthy while the balance is low
+    UnhealthyWhenLow bool `yaml:"unhealthy_when_low,omitempty"`
+}
+
+// AccountBalance is the decoded balance of a watched account.
+type AccountBalance struct {
+    Address string
+    Wei     *big.Int
+    // Min is the account's min_wei, nil when not set.
+    Min *big.Int
+}
+
+// low reports whether the balance is below the account's min_wei.
+func (b AccountBalance) low() bool {
+    return b.Min != nil && b.Wei.Cmp(b.Min) < 0
+}
+
+var addressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// blockTags are the named blocks eth_getBalance accepts besides numbers.
+var blockTags = map[string]bool{"latest": true, "pending": true, "earliest": true, "safe": true, "finalized": true}
+
+func (b Balance) block() string {
+    if b.Block == "" {
+        return "latest"
+    }
+    return b.Block
+}
+
+// label is the address label of the account's series, lowercased so
+// checksummed and plain spellings share them.
+func (b Balance) label() string {
+    return strings.ToLower(b.Address)
+}
+
+// validateBalances checks the watched accounts of an endpoint.
+func validateBalances(endpoint Endpoint) []error {
+    var problems []error
+    for i, balance := range endpoint.Balances {
+        if !addressPattern.MatchString(balance.Address) {
+            problems = append(problems, fmt.Errorf("endpoint %s: balances[%d]: invalid address %q", endpoint.Name, i, balance.Address))
+        }
+        if _, isNumber := parseQuantity(balance.Block); balance.Block != "" && !blockTags[balance.Block] && !(isNumber && strings.HasPrefix(balance.Block, "0x")) {
+            problems = append(problems, fmt.Errorf("endpoint %s: balances[%d]: invalid block %q, expected a block tag such as latest or a hex number", endpoint.Name, i, balance.Block))
+        }
+        if balance.MinWei != "" {
+            if min, ok := parseQuantity(balance.MinWei); !ok || min.Sign() < 0 {
+                problems = append(problems, fmt.Errorf("endpoint %s: balances[%d]: invalid min_wei %q", endpoint.Name, i, balance.MinWei))
+            }
+        }
+        if balance.UnhealthyWhenLow && balance.MinWei == "" {
+            problems = append(problems, fmt.Errorf("endpoint %s: balances[%d]: unhealthy_when_low requires min_wei", endpoint.Name, i))
+        }
+    }
+    return problems
+}
+
+// callBalances reads the balances of the watched accounts in one batch.
+func callBalances(ctx context.Context, client RPCClient, balances []Balance) ([]json.RawMessage, error) {
+    raws := make([]json.RawMessage, len(balances))
+    batch := make([]rpc.BatchElem, len(balances))
+    for i, balance := range balances {
+        batch[i] = rpc.BatchElem{Method: balanceMethod, Args: []interface{}{balance.Address, balance.block()}, Result: &raws[i]}
+    }
+    if err := client.BatchCallContext(ctx, batch); err != nil {
+        return nil, err
+    }
+    for i, elem := range batch {
+        if elem.Error != nil {
+            return nil, fmt.Errorf("%s %s: %w", balanceMethod, balances[i].Address, elem.Error)
+        }
+    }
+    return raws, nil
+}
+
+// decodeBalances decodes the balances and returns their log summaries.
+func decodeBalances(balances []Balance, raws []json.RawMessage) ([]AccountBalance, []string, error) {
+    decoded := make([]AccountBalance, len(balances))
+    summaries := make([]string, len(balances))
+    for i, balance := range balances {
+        wei, err := decodeBigQuantity(raws[i])
+        if err != nil {
+            return nil, nil, fmt.Errorf("%s %s: %v", balanceMethod, balance.Address, err)
+        }
+        decoded[i] = AccountBalance{Address: balance.label(), Wei: wei}
+        if min, ok := parseQuantity(balance.MinWei); ok {
+            decoded[i].Min = min
+        }
+        summaries[i] = fmt.Sprintf("balance of %s: %s wei", balance.Address, wei)
+    }
+    return decoded, summaries, nil
+}
+
+// verifyBalances returns an error for the first low balance of an account
+// that makes the endpoint unhealthy.
+func verifyBalances(balances []Balance, decoded []AccountBalance) error {
+    for i, balance := range balances {
+        if balance.UnhealthyWhenLow && decoded[i].low() {
+            return fmt.Errorf("balance of %s is %s wei, below the minimum of %s", balance.Address, decoded[i].Wei, balance.MinWei)
+        }
+    }
+    return nil
+}
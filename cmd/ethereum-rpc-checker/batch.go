@@ -0,0 +1,126 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+
+    "github.com/ethereum/go-ethereum/rpc"
+)
+
+// methodArgs are the parameters sent with methods that need some.
+var methodArgs = map[string][]interface{}{
+    blockMethod: {"latest", false},
+}
+
+// endpointExtraMethods returns the additional methods to call alongside the
+// main method, without duplicates of the main method itself. eth_chainId and
+// net_version are added when the endpoint expects a chain ID or a network
+// ID, eth_getBlockByNumber when it reports its head age, and txpool_status
+// when it reports its transaction pool.
+func endpointExtraMethods(endpoint Endpoint, method string) []string {
+    var extras []string
+    seen := map[string]bool{method: true}
+    methods := endpoint.Methods
+    if endpoint.ChainID != 0 {
+        methods = append(methods[:len(methods):len(methods)], chainIDMethod)
+    }
+    if endpoint.NetVersion != "" {
+        methods = append(methods[:len(methods):len(methods)], netVersionMethod)
+    }
+    if endpoint.HeadAge {
+        methods = append(methods[:len(methods):len(methods)], blockMethod)
+    }
+    if endpoint.TxPool {
+        methods = append(methods[:len(methods):len(methods)], txPoolMethod)
+    }
+    for _, m := range methods {
+        if !seen[m] {
+            seen[m] = true
+            extras = append(extras, m)
+        }
+    }
+    return extras
+}
+
+// Policies for additional methods the provider doesn't support.
+const (
+    unsupportedMethodsSkip      = "skip"
+    unsupportedMethodsUnhealthy = "unhealthy"
+)
+
+func isUnsupportedMethodsPolicy(policy string) bool {
+    return policy == "" || policy == unsupportedMethodsSkip || policy == unsupportedMethodsUnhealthy
+}
+
+// endpointOptionalMethods returns which of the additional methods may be
+// skipped when the provider doesn't support them: all of them under the skip
+// policy, except txpool_status when txpool_required is set.
+func endpointOptionalMethods(endpoint Endpoint, config Config, extras []string) map[string]bool {
+    optional := make(map[string]bool, len(extras))
+    if resolveEndpoint(config, endpoint).UnsupportedMethods != unsupportedMethodsSkip {
+        return optional
+    }
+    for _, m := range extras {
+        optional[m] = m != txPoolMethod || !endpoint.TxPoolRequired
+    }
+    return optional
+}
+
+// callMethods calls every method and returns their raw results in order. A
+// single method is a plain call; several are sent in one JSON-RPC batch. An
+// optional method the provider doesn't support is left with a nil result
+// instead of failing the call.
+func callMethods(ctx context.Context, client RPCClient, methods []string, optional map[string]bool) ([]json.RawMessage, error) {
+    raws := make([]json.RawMessage, len(methods))
+    if len(methods) == 1 {
+        return raws, client.CallContext(ctx, &raws[0], methods[0], methodArgs[methods[0]]...)
+    }
+
+    batch := make([]rpc.BatchElem, len(methods))
+    for i, m := range methods {
+        batch[i] = rpc.BatchElem{Method: m, Args: methodArgs[m], Result: &raws[i]}
+    }
+    if err := client.BatchCallContext(ctx, batch); err != nil {
+        return nil, err
+    }
+    if batch[0].Error != nil {
+        return nil, batch[0].Error
+    }
+    for i, elem := range batch[1:] {
+        if elem.Error != nil && optional[elem.Method] && isMethodNotFound(elem.Error) {
+            raws[i+1] = nil
+            continue
+        }
+        if elem.Error != nil {
+            return nil, fmt.Errorf("%s: %w", elem.Method, elem.Error)
+        }
+    }
+    return raws, nil
+}
+
+// handleExtraResults feeds each additional method's result to the handler of
+// its result type. It returns the log summaries of the handled results.
+func handleExtraResults(extras []string, raws []json.RawMessage, result *CheckResult) ([]string, error) {
+    var summaries []string
+    for i, m := range extras {
+        resultType := resultTypeFor(m, "", resultTypeNone)
+        // DATA results are only exposed for the main method
+        if resultType == resultTypeNone || resultType == resultTypeData {
+            continue
+        }
+        // Skipped by callMethods as not supported
+        if raws[i] == nil {
+            continue
+        }
+        if err := checkEmptyResult(raws[i], resultType); err != nil {
+            return nil, fmt.Errorf("%s: %w", m, err)
+        }
+        summary, err := resultHandlers[resultType](raws[i], result)
+        if err != nil {
+            return nil, fmt.Errorf("%s: %v", m, err)
+        }
+        summaries = append(summaries, summary)
+    }
+    return summaries, nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "log/slog"
+    "math/big"
+    "time"
+)
+
+// CheckResult is the outcome of checking an endpoint once.
+type CheckResult struct {
+    Endpoint string
+    // Healthy is the outcome of this check alone.
+    Healthy bool
+    // Reported is the health reported in rpc_healthy, /status and
+    // notifications, i.e. Healthy debounced by failure_threshold and
+    // success_threshold. See stateStore.observeHealth.
+    Reported bool
+    // BlockNumber is only meaningful when HasBlockNumber is set, i.e. when
+    // the main method's result type is block_number.
+    BlockNumber    uint64
+    HasBlockNumber bool
+    // Latency is the duration of the last call the endpoint answered, zero if it never did.
+    Latency time.Duration
+    Err     error
+    // Cancelled is set when the check was interrupted by a shutdown or reload,
+    // or skipped while the endpoint is rate limited, and says nothing about
+    // the endpoint.
+    Cancelled bool
+    // TraceID is the trace of the check when tracing is enabled, attached
+    // to its latency as an exemplar.
+    TraceID string
+    // ErrorCategory classifies Err, e.g. dns, tls or rpc. See classifyError.
+    ErrorCategory string
+    // ConsecutiveFailures is the number of failed checks in a row including this one.
+    ConsecutiveFailures int
+    // Upstream is which of the endpoint's URLs was checked last, see upstreamLabel.
+    Upstream string
+    // Result is the raw result of the main method, and Summary what its
+    // result handler made of it, e.g. "network 1". Summary is empty for
+    // block_number results.
+    Result  json.RawMessage
+    Summary string
+
+    // Values decoded from the results of additional methods, nil when not called.
+    Syncing       *SyncStatus
+    PeerCount     *uint64
+    GasPriceGwei  *float64
+    ChainID       *big.Int
+    NetVersion    *string
+    TxPool        *TxPoolStatus
+    // Data is the DATA result of the main method when its result type is data.
+    Data          *string
+    // HeadTimestamp is the timestamp of the latest block.
+    HeadTimestamp *time.Time
+    // Balances holds the decoded balances of the watched accounts.
+    Balances      []AccountBalance
+    // Extracted holds the values of the endpoint's extractors by metric name,
+    // nil when the calls failed.
+    Extracted     map[string]float64
+}
+
+// TxPoolStatus is the decoded result of txpool_status.
+type TxPoolStatus struct {
+    Pending uint64
+    Queued  uint64
+}
+
+// SyncStatus is the decoded result of eth_syncing.
+type SyncStatus struct {
+    Syncing bool
+    // Gap is the number of blocks between the current and highest known block.
+    Gap uint64
+}
+
+// endpointLogName is how an endpoint appears in log messages. Debug mode adds the masked URL.
+func endpointLogName(endpoint Endpoint, debug bool) string {
+    if debug {
+        return fmt.Sprintf("%s (%s)", endpoint.Name, maskSensitiveInfo(endpoint.URL))
+    }
+    return endpoint.Name
+}
+
+// recordCheckResult updates the Prometheus metrics from the outcome of a check.
+func (m *Metrics) recordCheckResult(endpoint Endpoint, config Config, result CheckResult) {
+    if result.Cancelled {
+        return
+    }
+    name := result.Endpoint
+    m.inc(m.checksTotal, "rpc_checks_total", name)
+    m.set(m.lastCheck, "rpc_last_check_timestamp_seconds", float64(time.Now().UnixNano())/1e9, name)
+    m.set(m.consecutiveFailures, "rpc_consecutive_failures", float64(result.ConsecutiveFailures), name)
+    if result.Latency > 0 {
+        m.observeWithTrace(m.rpcLatency, "rpc_latency_seconds", result.Latency.Seconds(), result.TraceID, name)
+    }
+
+    // Decoded values are recorded even if a later step failed, e.g. to show
+    // which chain an endpoint is really on
+    if result.Syncing != nil {
+        syncing := 0.0
+        if result.Syncing.Syncing {
+            syncing = 1
+        }
+        m.set(m.nodeSyncing, "node_syncing", syncing, name)
+        m.set(m.syncGap, "sync_gap_blocks", float64(result.Syncing.Gap), name)
+    }
+    if result.PeerCount != nil {
+        m.set(m.peerCount, "peer_count", float64(*result.PeerCount), name)
+    }
+    if result.TxPool != nil {
+        m.set(m.txPoolPending, "txpool_pending", float64(result.TxPool.Pending), name)
+        m.set(m.txPoolQueued, "txpool_queued", float64(result.TxPool.Queued), name)
+    }
+    if result.GasPriceGwei != nil {
+        m.set(m.gasPrice, "gas_price_gwei", *result.GasPriceGwei, name)
+    }
+    if result.HeadTimestamp != nil {
+        m.set(m.headAge, "head_age_seconds", time.Since(*result.HeadTimestamp).Seconds(), name)
+    }
+    if result.ChainID != nil {
+        m.deleteEndpoint(m.chainIDInfo.MetricVec, "chain_id_info", name)
+        m.set(m.chainIDInfo, "chain_id_info", 1, name, result.ChainID.String())
+    }
+    if result.NetVersion != nil {
+        m.deleteEndpoint(m.netVersionInfo.MetricVec, "net_version_info", name)
+        m.set(m.netVersionInfo, "net_version_info", 1, name, *result.NetVersion)
+    }
+    if result.Data != nil {
+        m.deleteEndpoint(m.resultInfo.MetricVec, "rpc_result_info", name)
+        m.set(m.resultInfo, "rpc_result_info", 1, name, resolveEndpoint(config, endpoint).Method, *result.Data)
+    }
+
+    if len(endpoint.FallbackURLs) > 0 {
+        m.deleteEndpoint(m.upstream.MetricVec, "rpc_upstream", name)
+        if result.Healthy {
+            m.set(m.upstream, "rpc_upstream", 1, name, result.Upstream)
+        }
+    }
+
+    for _, balance := range result.Balances {
+        m.set(m.accountBalance, "account_balance_wei", bigToFloat(balance.Wei), name, balance.Address)
+        if balance.Min != nil {
+            low := 0.0
+            if balance.low() {
+                low = 1
+            }
+            m.set(m.balanceLow, "account_balance_low", low, name, balance.Address)
+        }
+    }
+
+    if result.Extracted != nil {
+        m.recordExtracted(endpoint, result.Extracted)
+    }
+
+    reported := 0.0
+    if result.Reported {
+        reported = 1
+    }
+    m.set(m.rpcHealthy, "rpc_healthy", reported, name)
+    if !result.Healthy {
+        m.recordDrift(endpoint, result)
+        m.inc(m.rpcErrors, "rpc_errors_total", name, errorCategoryLabel(result.ErrorCategory))
+        m.set(m.checkSuccess, "rpc_check_success", 0, name)
+        m.inc(m.checkFailures, "rpc_check_failures_total", name)
+        return
+    }
+    m.set(m.checkSuccess, "rpc_check_success", 1, name)
+    m.set(m.lastSuccess, "rpc_last_success_timestamp_seconds", float64(time.Now().UnixNano())/1e9, name)
+    if !result.HasBlockNumber {
+        m.recordDrift(endpoint, result)
+        return
+    }
+
+    m.set(m.blockNumber, "block_number", float64(result.BlockNumber), name)
+    m.recordDrift(endpoint, result)
+    if threshold := resolveEndpoint(config, endpoint).StallThreshold; threshold > 0 {
+        unchanged := endpointStates.observeBlock(name, result.BlockNumber)
+        if unchanged >= threshold {
+            slog.Warn(fmt.Sprintf("🧊 Block height on %s stuck at %d for %d consecutive checks", endpointLogName(endpoint, config.Debug), result.BlockNumber, unchanged),
+                "endpoint", name, "block_number", result.BlockNumber, "unchanged_checks", unchanged)
+            m.set(m.blockStalled, "block_stalled", 1, name)
+        } else {
+            m.set(m.blockStalled, "block_stalled", 0, name)
+        }
+    }
+}
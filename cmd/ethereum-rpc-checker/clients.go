@@ -0,0 +1,179 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log/slog"
+    "sync"
+    "time"
+
+    "github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+    // reconnectBackoff is the delay after the first failed reconnection of a
+    // persistent client, doubled on each further failure.
+    reconnectBackoff = time.Second
+    // maxReconnectBackoff caps the delay between reconnection attempts.
+    maxReconnectBackoff = time.Minute
+)
+
+// clientCache keeps one RPC client per endpoint URL so connections are
+// reused across checks instead of being dialed on every tick. Clients are
+// keyed by endpoint name since dial options such as headers are per
+// endpoint, and then by URL since an endpoint may have fallback URLs.
+type clientCache struct {
+    mu      sync.Mutex
+    clients map[string]map[string]RPCClient
+    // reconnects tracks the persistent clients whose connection dropped,
+    // keyed like clients, until they are reconnected
+    reconnects map[string]map[string]*reconnectState
+}
+
+// reconnectState is the reconnection progress of a dropped persistent client.
+type reconnectState struct {
+    failures int
+    next     time.Time
+}
+
+func newClientCache() *clientCache {
+    return &clientCache{
+        clients:    make(map[string]map[string]RPCClient),
+        reconnects: make(map[string]map[string]*reconnectState),
+    }
+}
+
+// reconnectDelay returns the wait after failed reconnection n (starting at 1).
+func reconnectDelay(n int) time.Duration {
+    d := reconnectBackoff << (n - 1)
+    if d <= 0 || d > maxReconnectBackoff {
+        d = maxReconnectBackoff
+    }
+    return d
+}
+
+// get returns the cached client for the endpoint, dialing a new one on first
+// use. A dropped persistent client is redialed with exponential backoff;
+// until the next attempt is due, get fails without dialing.
+func (c *clientCache) get(ctx context.Context, endpoint Endpoint) (RPCClient, error) {
+    name := endpoint.Name
+    c.mu.Lock()
+    client, ok := c.clients[name][endpoint.URL]
+    reconnect := c.reconnects[name][endpoint.URL]
+    if !ok && reconnect != nil {
+        if wait := time.Until(reconnect.next); wait > 0 {
+            c.mu.Unlock()
+            return nil, fmt.Errorf("reconnecting, next attempt in %s", wait.Round(time.Second))
+        }
+    }
+    c.mu.Unlock()
+    if ok {
+        return client, nil
+    }
+
+    // Dial without holding the lock so a slow endpoint doesn't block the others
+    if reconnect != nil {
+        metrics.inc(metrics.reconnects, "rpc_reconnects_total", name)
+    }
+    client, err := rpcDial(ctx, endpoint)
+    if err != nil {
+        if reconnect != nil {
+            c.reconnectFailed(endpoint, err)
+        }
+        return nil, err
+    }
+
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if reconnect != nil {
+        delete(c.reconnects[name], endpoint.URL)
+        slog.Info(fmt.Sprintf("🔌 Reconnected to %s", name), "endpoint", name, "failures", reconnect.failures)
+    }
+    if existing, ok := c.clients[name][endpoint.URL]; ok {
+        client.Close()
+        return existing, nil
+    }
+    if c.clients[name] == nil {
+        c.clients[name] = make(map[string]RPCClient)
+    }
+    c.clients[name][endpoint.URL] = client
+    return client, nil
+}
+
+// reconnectFailed schedules the next reconnection of the endpoint's client.
+func (c *clientCache) reconnectFailed(endpoint Endpoint, err error) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    reconnect := c.reconnects[endpoint.Name][endpoint.URL]
+    if reconnect == nil {
+        // Forgotten by a reload meanwhile
+        return
+    }
+    reconnect.failures++
+    wait := reconnectDelay(reconnect.failures)
+    reconnect.next = time.Now().Add(wait)
+    slog.Warn(fmt.Sprintf("🔌 Reconnecting to %s failed: %v, next attempt in %s", endpoint.Name, err, wait),
+        "endpoint", endpoint.Name, "error", err, "failures", reconnect.failures)
+}
+
+// discard closes and forgets the clients for name so the next check redials.
+func (c *clientCache) discard(name string) {
+    c.mu.Lock()
+    clients := c.clients[name]
+    delete(c.clients, name)
+    delete(c.reconnects, name)
+    c.mu.Unlock()
+    for _, client := range clients {
+        client.Close()
+    }
+}
+
+// discardURL closes and forgets the client for the endpoint's URL only,
+// keeping the connections to its other URLs. A WebSocket or IPC client held
+// a connection, so it is reconnected with backoff rather than on every check.
+func (c *clientCache) discardURL(endpoint Endpoint) {
+    c.mu.Lock()
+    client, ok := c.clients[endpoint.Name][endpoint.URL]
+    delete(c.clients[endpoint.Name], endpoint.URL)
+    if ok && supportsSubscriptions(endpoint.URL) {
+        if c.reconnects[endpoint.Name] == nil {
+            c.reconnects[endpoint.Name] = make(map[string]*reconnectState)
+        }
+        c.reconnects[endpoint.Name][endpoint.URL] = &reconnectState{next: time.Now()}
+        slog.Warn(fmt.Sprintf("🔌 Lost connection to %s, reconnecting", endpoint.Name), "endpoint", endpoint.Name)
+    }
+    c.mu.Unlock()
+    if ok {
+        client.Close()
+    }
+}
+
+// closeAll closes every cached client.
+func (c *clientCache) closeAll() {
+    c.mu.Lock()
+    clients := c.clients
+    c.clients = make(map[string]map[string]RPCClient)
+    c.reconnects = make(map[string]map[string]*reconnectState)
+    c.mu.Unlock()
+    for _, urls := range clients {
+        for _, client := range urls {
+            client.Close()
+        }
+    }
+}
+
+// isConnectionError reports whether err suggests the underlying connection
+// is unusable. JSON-RPC and HTTP status errors mean the server answered, so
+// the client is kept.
+func isConnectionError(err error) bool {
+    var rpcErr rpc.Error
+    if errors.As(err, &rpcErr) {
+        return false
+    }
+    var httpErr rpc.HTTPError
+    if errors.As(err, &httpErr) {
+        return false
+    }
+    return true
+}
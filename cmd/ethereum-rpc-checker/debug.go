@@ -0,0 +1,19 @@
+package main
+
+import (
+    "expvar"
+    "net/http"
+    "net/http/pprof"
+)
+
+// registerDebugHandlers adds the pprof profiles under /debug/pprof/ and the
+// expvar variables under /debug/vars. They expose internals of the process,
+// so they are only served when prometheus.debug_endpoints is set.
+func registerDebugHandlers(mux *http.ServeMux) {
+    mux.HandleFunc("/debug/pprof/", pprof.Index)
+    mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+    mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+    mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+    mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+    mux.Handle("/debug/vars", expvar.Handler())
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+    "context"
+    "strconv"
+    "time"
+)
+
+// Colors of the Discord embeds, as RGB integers.
+const (
+    discordColorHealthy   = 0x2ecc71
+    discordColorUnhealthy = 0xe74c3c
+)
+
+// discordMaxFieldLength is the longest value, in characters, Discord accepts
+// in an embed field.
+const discordMaxFieldLength = 1024
+
+// discordNotifier posts health changes to a Discord webhook as embeds.
+type discordNotifier struct {
+    webhookURL string
+}
+
+type discordEmbed struct {
+    Title     string              `json:"title"`
+    Color     int                 `json:"color"`
+    Timestamp string              `json:"timestamp"`
+    Fields    []discordEmbedField `json:"fields,omitempty"`
+}
+
+type discordEmbedField struct {
+    Name   string `json:"name"`
+    Value  string `json:"value"`
+    Inline bool   `json:"inline,omitempty"`
+}
+
+func (discordNotifier) Name() string { return "Discord" }
+
+func (n discordNotifier) Notify(ctx context.Context, change StatusChange) error {
+    embed := discordEmbed{
+        Title:     "✅ Endpoint is healthy again",
+        Color:     discordColorHealthy,
+        Timestamp: change.Time.Format(time.RFC3339),
+        Fields:    []discordEmbedField{{Name: "Endpoint", Value: change.Endpoint, Inline: true}},
+    }
+    if !change.Healthy {
+        embed.Title = "🚨 Endpoint is unhealthy"
+        embed.Color = discordColorUnhealthy
+    }
+    if change.BlockNumber != nil {
+        embed.Fields = append(embed.Fields, discordEmbedField{Name: "Block", Value: strconv.FormatUint(*change.BlockNumber, 10), Inline: true})
+    }
+    if change.Err != nil {
+        embed.Fields = append(embed.Fields, discordEmbedField{Name: "Error", Value: truncate(change.Err.Error(), discordMaxFieldLength)})
+    }
+    return postWebhook(ctx, n.webhookURL, map[string][]discordEmbed{"embeds": {embed}})
+}
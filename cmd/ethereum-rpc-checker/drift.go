@@ -0,0 +1,65 @@
+package main
+
+import "sync"
+
+// groupHeights holds the latest block height of every healthy endpoint that
+// belongs to a group, so endpoints serving the same chain can be compared.
+type groupHeights struct {
+    mu        sync.Mutex
+    endpoints map[string]groupHeight
+}
+
+type groupHeight struct {
+    group  string
+    height uint64
+}
+
+func newGroupHeights() *groupHeights {
+    return &groupHeights{endpoints: make(map[string]groupHeight)}
+}
+
+// observe records an endpoint's height and returns how far each endpoint of
+// its group is below the group's highest block.
+func (g *groupHeights) observe(name, group string, height uint64) map[string]uint64 {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+
+    g.endpoints[name] = groupHeight{group: group, height: height}
+    var highest uint64
+    for _, h := range g.endpoints {
+        if h.group == group && h.height > highest {
+            highest = h.height
+        }
+    }
+    drifts := make(map[string]uint64)
+    for member, h := range g.endpoints {
+        if h.group == group {
+            drifts[member] = highest - h.height
+        }
+    }
+    return drifts
+}
+
+// remove leaves an endpoint out of its group's maximum, e.g. after a failed check.
+func (g *groupHeights) remove(name string) {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    delete(g.endpoints, name)
+}
+
+var groups = newGroupHeights()
+
+// recordDrift updates the drift of the endpoints in the group of a checked endpoint.
+func (m *Metrics) recordDrift(endpoint Endpoint, result CheckResult) {
+    if endpoint.Group == "" {
+        return
+    }
+    if !result.Healthy || !result.HasBlockNumber {
+        groups.remove(endpoint.Name)
+        m.deleteEndpoint(m.blockDrift.MetricVec, "block_drift", endpoint.Name)
+        return
+    }
+    for name, drift := range groups.observe(endpoint.Name, endpoint.Group, result.BlockNumber) {
+        m.set(m.blockDrift, "block_drift", float64(drift), name, endpoint.Group)
+    }
+}
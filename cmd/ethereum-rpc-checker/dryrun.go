@@ -0,0 +1,107 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "strings"
+)
+
+// printPlan writes what the checker would do with config, with defaults and
+// overrides resolved per endpoint. It backs the -dry-run flag, so it must not
+// touch the network, and its output is kept stable for diffing in CI.
+func printPlan(w io.Writer, config Config) {
+    fmt.Fprintf(w, "Prometheus address: %s\n", config.Prometheus.Address)
+    if config.Pushgateway.URL != "" {
+        fmt.Fprintf(w, "Pushgateway: %s\n", maskSensitiveInfo(config.Pushgateway.URL))
+    }
+    if config.Tracing.Endpoint != "" {
+        fmt.Fprintf(w, "Tracing: %s (propagate %v)\n", maskSensitiveInfo(config.Tracing.Endpoint), config.Tracing.Propagate)
+    }
+    maxChecks := config.MaxConcurrentChecks
+    if maxChecks <= 0 {
+        maxChecks = defaultMaxConcurrentChecks
+    }
+    fmt.Fprintf(w, "Max concurrent checks: %d\n", maxChecks)
+    fmt.Fprintf(w, "Jitter: %g\n", config.Jitter)
+    fmt.Fprintf(w, "Startup grace: %s\n", config.StartupGrace.Duration())
+    fmt.Fprintf(w, "Endpoints: %d\n", len(config.Endpoints))
+
+    for i, endpoint := range config.Endpoints {
+        resolved := resolveEndpoint(config, endpoint)
+        method := resolved.Method
+        extras := endpointExtraMethods(endpoint, method)
+        calls := append([]string{method}, extras...)
+        calls = append(calls, extractorMethods(endpoint.Extract, calls)...)
+        interval := resolved.Interval.Duration()
+
+        fmt.Fprintf(w, "\n%s:\n", endpoint.Name)
+        fmt.Fprintf(w, "  url: %s\n", maskSensitiveInfo(endpoint.URL))
+        for i, fallback := range endpoint.FallbackURLs {
+            fmt.Fprintf(w, "  %s: %s\n", upstreamLabel(i+1), maskSensitiveInfo(fallback))
+        }
+        if endpoint.Group != "" {
+            fmt.Fprintf(w, "  group: %s\n", endpoint.Group)
+        }
+        fmt.Fprintf(w, "  interval: %s\n", interval)
+        fmt.Fprintf(w, "  first check offset: %s\n", startOffset(i, len(config.Endpoints), interval))
+        fmt.Fprintf(w, "  calls: %s\n", strings.Join(calls, ", "))
+        fmt.Fprintf(w, "  result type: %s\n", resultTypeFor(method, endpoint.ResultType, resultTypeBlockNumber))
+        if endpoint.Expect != nil {
+            fmt.Fprintf(w, "  expect: %s\n", *endpoint.Expect)
+        }
+        fmt.Fprintf(w, "  dial timeout: %s\n", resolved.DialTimeout.Duration())
+        fmt.Fprintf(w, "  call timeout: %s\n", resolved.CallTimeout.Duration())
+        transport := resolved.Transport
+        fmt.Fprintf(w, "  transport: max idle conns per host %d, idle conn timeout %s, tls handshake timeout %s, keep alive %s, max response size %d\n",
+            transport.MaxIdleConnsPerHost, transport.IdleConnTimeout.Duration(), transport.TLSHandshakeTimeout.Duration(), transport.KeepAlive.Duration(), transport.MaxResponseSize)
+        if endpoint.TLSServerName != "" {
+            fmt.Fprintf(w, "  tls server name: %s\n", endpoint.TLSServerName)
+        }
+        if endpoint.HostHeader != "" {
+            fmt.Fprintf(w, "  host header: %s\n", endpoint.HostHeader)
+        }
+        if endpoint.Proxy != "" {
+            fmt.Fprintf(w, "  proxy: %s\n", maskSensitiveInfo(endpoint.Proxy))
+        } else {
+            fmt.Fprintf(w, "  proxy: from environment\n")
+        }
+        fmt.Fprintf(w, "  retries: %d (backoff %s)\n", resolved.Retry.retries, resolved.Retry.backoff)
+        fmt.Fprintf(w, "  stall threshold: %d\n", resolved.StallThreshold)
+        fmt.Fprintf(w, "  failure threshold: %d, success threshold: %d\n", resolved.FailureThreshold, resolved.SuccessThreshold)
+        fmt.Fprintf(w, "  insecure skip verify: %v\n", *resolved.InsecureSkipVerify)
+        fmt.Fprintf(w, "  redirects: %s\n", resolved.Redirects)
+        fmt.Fprintf(w, "  unsupported methods: %s\n", resolved.UnsupportedMethods)
+        fmt.Fprintf(w, "  user agent: %s\n", resolved.UserAgent)
+        if endpoint.LatencySLA > 0 {
+            fmt.Fprintf(w, "  latency sla: %s\n", endpoint.LatencySLA.Duration())
+        }
+        if endpoint.RateLimit > 0 {
+            fmt.Fprintf(w, "  rate limit: %g requests per second\n", endpoint.RateLimit)
+        }
+        if endpoint.ChainID != 0 {
+            fmt.Fprintf(w, "  chain id: %d\n", endpoint.ChainID)
+        }
+        if endpoint.TxPoolRequired {
+            fmt.Fprintf(w, "  txpool required: true\n")
+        }
+        if endpoint.NetVersion != "" {
+            fmt.Fprintf(w, "  net version: %s\n", endpoint.NetVersion)
+        }
+        for _, balance := range endpoint.Balances {
+            fmt.Fprintf(w, "  balance: %s at %s", balance.Address, balance.block())
+            if balance.MinWei != "" {
+                fmt.Fprintf(w, ", min %s wei", balance.MinWei)
+            }
+            if balance.UnhealthyWhenLow {
+                fmt.Fprintf(w, ", unhealthy when low")
+            }
+            fmt.Fprintln(w)
+        }
+        for _, extractor := range endpoint.Extract {
+            fmt.Fprintf(w, "  extract: %s from %s at %q\n", extractor.Metric, extractor.Method, extractor.Path)
+        }
+        if len(endpoint.Headers) > 0 {
+            fmt.Fprintf(w, "  headers: %s\n", endpoint.Headers)
+        }
+    }
+}
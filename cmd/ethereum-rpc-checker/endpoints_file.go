@@ -0,0 +1,71 @@
+package main
+
+import (
+    "fmt"
+    "io/ioutil"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    "gopkg.in/yaml.v3"
+)
+
+// loadEndpointFiles reads the endpoints of every file matching pattern, a
+// glob relative to dir unless absolute. A file holds either a list of
+// endpoints or a mapping with an endpoints key, and may use ${VAR} like the
+// config file itself.
+func loadEndpointFiles(pattern, dir string) ([]Endpoint, error) {
+    if !filepath.IsAbs(pattern) {
+        pattern = filepath.Join(dir, pattern)
+    }
+    files, err := filepath.Glob(pattern)
+    if err != nil {
+        return nil, fmt.Errorf("invalid endpoints_file pattern %q: %v", pattern, err)
+    }
+    if len(files) == 0 {
+        return nil, fmt.Errorf("endpoints_file %q matches no files", pattern)
+    }
+    sort.Strings(files)
+
+    var endpoints []Endpoint
+    for _, file := range files {
+        loaded, err := loadEndpointFile(file)
+        if err != nil {
+            return nil, fmt.Errorf("%s: %v", file, err)
+        }
+        endpoints = append(endpoints, loaded...)
+    }
+    return endpoints, nil
+}
+
+func loadEndpointFile(file string) ([]Endpoint, error) {
+    data, err := ioutil.ReadFile(file)
+    if err != nil {
+        return nil, err
+    }
+    expanded, err := expandEnv(string(data))
+    if err != nil {
+        return nil, err
+    }
+
+    var node yaml.Node
+    if err := yaml.Unmarshal([]byte(expanded), &node); err != nil {
+        return nil, err
+    }
+    if len(node.Content) == 0 {
+        return nil, nil
+    }
+
+    dec := yaml.NewDecoder(strings.NewReader(expanded))
+    dec.KnownFields(true)
+    if node.Content[0].Kind == yaml.SequenceNode {
+        var endpoints []Endpoint
+        err = dec.Decode(&endpoints)
+        return endpoints, err
+    }
+    var wrapped struct {
+        Endpoints []Endpoint `yaml:"endpoints"`
+    }
+    err = dec.Decode(&wrapped)
+    return wrapped.Endpoints, err
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+    "context"
+    "crypto/tls"
+    "crypto/x509"
+    "errors"
+    "net"
+    "regexp"
+
+    "github.com/ethereum/go-ethereum/rpc"
+)
+
+// Error categories of failed checks, the category label of blockchain_rpc_errors_total.
+const (
+    errorCategoryDNS         = "dns"
+    errorCategoryConnection  = "connection"
+    errorCategoryTLS         = "tls"
+    errorCategoryTimeout     = "timeout"
+    errorCategoryHTTP        = "http"
+    errorCategoryRateLimited = "rate_limited"
+    errorCategoryRPC         = "rpc"
+    errorCategoryDecode      = "decode"
+    errorCategoryChainID     = "chain_id"
+    errorCategoryLatencySLA  = "latency_sla"
+    errorCategoryUnexpected  = "unexpected_result"
+    errorCategoryLowBalance  = "low_balance"
+    errorCategoryTooLarge    = "response_too_large"
+    errorCategoryEmpty       = "empty_result"
+    errorCategoryNotFound    = "method_not_found"
+)
+
+// errorCategories are the only values of the category label, so a new error
+// can't add series.
+var errorCategories = map[string]bool{
+    errorCategoryDNS:         true,
+    errorCategoryConnection:  true,
+    errorCategoryTLS:         true,
+    errorCategoryTimeout:     true,
+    errorCategoryHTTP:        true,
+    errorCategoryRateLimited: true,
+    errorCategoryRPC:         true,
+    errorCategoryDecode:      true,
+    errorCategoryChainID:     true,
+    errorCategoryLatencySLA:  true,
+    errorCategoryUnexpected:  true,
+    errorCategoryLowBalance:  true,
+    errorCategoryTooLarge:    true,
+    errorCategoryEmpty:       true,
+    errorCategoryNotFound:    true,
+}
+
+// errorCategoryLabel returns category if it is one of errorCategories and
+// connection, the catch-all of classifyError, otherwise.
+func errorCategoryLabel(category string) string {
+    if errorCategories[category] {
+        return category
+    }
+    return errorCategoryConnection
+}
+
+// classifyError maps a dial or call error to its category by inspecting the
+// error chain. Decode, empty result, chain ID and network ID, latency SLA,
+// unexpected result and low balance failures are categorized where they occur.
+func classifyError(err error) string {
+    if errors.Is(err, errResponseTooLarge) {
+        return errorCategoryTooLarge
+    }
+    var dnsErr *net.DNSError
+    if errors.As(err, &dnsErr) {
+        return errorCategoryDNS
+    }
+    if isTLSError(err) {
+        return errorCategoryTLS
+    }
+    var netErr net.Error
+    if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+        return errorCategoryTimeout
+    }
+    if isMethodNotFound(err) {
+        return errorCategoryNotFound
+    }
+    var rpcErr rpc.Error
+    if errors.As(err, &rpcErr) {
+        return errorCategoryRPC
+    }
+    if isRateLimited(err) {
+        return errorCategoryRateLimited
+    }
+    var httpErr rpc.HTTPError
+    if errors.As(err, &httpErr) {
+        return errorCategoryHTTP
+    }
+    return errorCategoryConnection
+}
+
+// methodNotFoundCode is the JSON-RPC error code of an unknown method.
+const methodNotFoundCode = -32601
+
+// methodNotFoundMessage is geth's message for an unknown method, which some
+// providers send with a non-standard code. Looser matches would also catch
+// errors such as "block does not exist" and skip a failing check.
+var methodNotFoundMessage = regexp.MustCompile(`^the method \S+ does not exist/is not available$`)
+
+// isMethodNotFound reports whether err is the JSON-RPC error of a method the
+// provider doesn't support: the standard code, or geth's exact message.
+func isMethodNotFound(err error) bool {
+    var rpcErr rpc.Error
+    if !errors.As(err, &rpcErr) {
+        return false
+    }
+    return rpcErr.ErrorCode() == methodNotFoundCode || methodNotFoundMessage.MatchString(rpcErr.Error())
+}
+
+func isTLSError(err error) bool {
+    var verifyErr *tls.CertificateVerificationError
+    var recordErr tls.RecordHeaderError
+    var alertErr tls.AlertError
+    var authorityErr x509.UnknownAuthorityError
+    var hostnameErr x509.HostnameError
+    var invalidErr x509.CertificateInvalidError
+    return errors.As(err, &verifyErr) || errors.As(err, &recordErr) || errors.As(err, &alertErr) ||
+        errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr)
+}
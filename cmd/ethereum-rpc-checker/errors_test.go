@@ -0,0 +1,41 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "testing"
+)
+
+// testRPCError is a JSON-RPC error response.
+type testRPCError struct {
+    code    int
+    message string
+}
+
+func (e testRPCError) Error() string  { return e.message }
+func (e testRPCError) ErrorCode() int { return e.code }
+
+func TestIsMethodNotFound(t *testing.T) {
+    tests := []struct {
+        name string
+        err  error
+        want bool
+    }{
+        {name: "standard code", err: testRPCError{code: -32601, message: "Method not found"}, want: true},
+        {name: "geth message", err: testRPCError{code: -32000, message: "the method txpool_status does not exist/is not available"}, want: true},
+        {name: "wrapped", err: fmt.Errorf("txpool_status: %w", testRPCError{code: -32601, message: "Method not found"}), want: true},
+        // Other errors that happen to use the same words must still fail the check
+        {name: "missing block", err: testRPCError{code: -32000, message: "block does not exist"}},
+        {name: "state not available", err: testRPCError{code: -32000, message: "historical state not available"}},
+        {name: "not supported", err: testRPCError{code: -32000, message: "pending block is not supported"}},
+        {name: "not an RPC error", err: errors.New("the method txpool_status does not exist/is not available")},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := isMethodNotFound(tt.err); got != tt.want {
+                t.Errorf("isMethodNotFound(%v) = %v, want %v", tt.err, got, tt.want)
+            }
+        })
+    }
+}
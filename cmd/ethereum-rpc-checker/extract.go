@@ -0,0 +1,157 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+// Extractor formats select how the value at an extractor's path is parsed.
+const (
+    extractFormatHex     = "hex"
+    extractFormatDecimal = "decimal"
+)
+
+// Extractor reads a numeric value out of the result of an arbitrary method
+// and exposes it as a gauge, for chains whose health isn't a plain block number.
+type Extractor struct {
+    Method string `yaml:"method"`
+    // Path is a dot-separated path into the result, e.g. currentBlock or
+    // $.result.peers[0].height. A leading $ or result is optional, and an
+    // empty path selects the whole result.
+    Path   string `yaml:"path"`
+    Format string `yaml:"format,omitempty"` // hex (default) or decimal
+    // Metric is the gauge name, prefixed with the metrics namespace
+    Metric string `yaml:"metric"`
+}
+
+// validateExtractors checks the extractors of an endpoint.
+func validateExtractors(endpoint Endpoint) []error {
+    var problems []error
+    seen := make(map[string]bool)
+    for i, extractor := range endpoint.Extract {
+        if extractor.Method == "" {
+            problems = append(problems, fmt.Errorf("endpoint %s: extract[%d]: method cannot be empty", endpoint.Name, i))
+        }
+        switch extractor.Format {
+        case "", extractFormatHex, extractFormatDecimal:
+        default:
+            problems = append(problems, fmt.Errorf("endpoint %s: extract[%d]: unknown format %q", endpoint.Name, i, extractor.Format))
+        }
+        if !metricNamespacePattern.MatchString(extractor.Metric) {
+            problems = append(problems, fmt.Errorf("endpoint %s: extract[%d]: invalid metric name %q: must match %s", endpoint.Name, i, extractor.Metric, metricNamespacePattern))
+        } else if seen[extractor.Metric] {
+            problems = append(problems, fmt.Errorf("endpoint %s: extract[%d]: duplicate metric %s", endpoint.Name, i, extractor.Metric))
+        }
+        seen[extractor.Metric] = true
+    }
+    return problems
+}
+
+// extractorMethods returns the methods the extractors need that aren't
+// already in calls.
+func extractorMethods(extractors []Extractor, calls []string) []string {
+    seen := make(map[string]bool, len(calls))
+    for _, m := range calls {
+        seen[m] = true
+    }
+    var methods []string
+    for _, extractor := range extractors {
+        if !seen[extractor.Method] {
+            seen[extractor.Method] = true
+            methods = append(methods, extractor.Method)
+        }
+    }
+    return methods
+}
+
+// extractValues applies the extractors to the results of calls and returns
+// the values by metric. Extractors that fail are reported in errs and left
+// out, so one missing field doesn't hide the others.
+func extractValues(extractors []Extractor, calls []string, raws []json.RawMessage) (values map[string]float64, errs []error) {
+    results := make(map[string]json.RawMessage, len(calls))
+    for i, m := range calls {
+        results[m] = raws[i]
+    }
+    values = make(map[string]float64, len(extractors))
+    for _, extractor := range extractors {
+        value, err := extractValue(results[extractor.Method], extractor.Path, extractor.Format)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("%s: %s: %v", extractor.Metric, extractor.Method, err))
+            continue
+        }
+        values[extractor.Metric] = value
+    }
+    return values, errs
+}
+
+// pathIndexPattern matches the [n] array indexes of a JSONPath-style path.
+var pathIndexPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// extractPath splits a path into its segments, accepting both currentBlock
+// and $.result.currentBlock, and turning [n] indexes into segments. The
+// whole result has no segments.
+func extractPath(path string) []string {
+    path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+    path = pathIndexPattern.ReplaceAllString(path, ".$1")
+    path = strings.Trim(path, ".")
+    if path == "result" {
+        return nil
+    }
+    path = strings.TrimPrefix(path, "result.")
+    if path == "" {
+        return nil
+    }
+    return strings.Split(path, ".")
+}
+
+// extractValue walks path into raw and parses the value found there.
+func extractValue(raw json.RawMessage, path, format string) (float64, error) {
+    decoder := json.NewDecoder(bytes.NewReader(raw))
+    decoder.UseNumber()
+    var node any
+    if err := decoder.Decode(&node); err != nil {
+        return 0, fmt.Errorf("invalid result %s: %v", raw, err)
+    }
+
+    for _, segment := range extractPath(path) {
+        switch v := node.(type) {
+        case map[string]any:
+            child, ok := v[segment]
+            if !ok {
+                return 0, fmt.Errorf("no %q in result %s", segment, raw)
+            }
+            node = child
+        case []any:
+            index, err := strconv.Atoi(segment)
+            if err != nil || index < 0 || index >= len(v) {
+                return 0, fmt.Errorf("no index %q in result %s", segment, raw)
+            }
+            node = v[index]
+        default:
+            return 0, fmt.Errorf("no %q in result %s", segment, raw)
+        }
+    }
+
+    switch v := node.(type) {
+    case string:
+        if format == extractFormatDecimal {
+            return strconv.ParseFloat(v, 64)
+        }
+        value, err := hexToBig(v)
+        if err != nil {
+            return 0, err
+        }
+        return bigToFloat(value), nil
+    case json.Number:
+        if format != extractFormatDecimal {
+            return 0, fmt.Errorf("expected a hex string, got %s", v)
+        }
+        return v.Float64()
+    default:
+        return 0, fmt.Errorf("expected a number at %s, got %v", path, node)
+    }
+}
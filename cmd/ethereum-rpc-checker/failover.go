@@ -0,0 +1,42 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+)
+
+// upstreamPrimary labels the endpoint's url; its fallback URLs are labeled
+// fallback_1, fallback_2 and so on. Positions are used rather than the URLs
+// themselves, which often carry API keys in their path.
+const upstreamPrimary = "primary"
+
+func upstreamLabel(index int) string {
+    if index == 0 {
+        return upstreamPrimary
+    }
+    return fmt.Sprintf("fallback_%d", index)
+}
+
+// checkWithFailover checks the endpoint's url and, as long as the check
+// fails, its fallback URLs in priority order. The logical endpoint is healthy
+// if any of them is. The result of the last URL tried is returned, with
+// Upstream telling which one it was.
+func checkWithFailover(ctx context.Context, endpoint Endpoint, config Config) CheckResult {
+    urls := append([]string{endpoint.URL}, endpoint.FallbackURLs...)
+    var result CheckResult
+    for i, upstreamURL := range urls {
+        if i > 0 {
+            slog.Warn(fmt.Sprintf("🔀 Check of %s failed: %v, failing over to %s", endpointLogName(endpoint, config.Debug), result.Err, upstreamLabel(i)),
+                "endpoint", endpoint.Name, "upstream", upstreamLabel(i), "error", result.Err)
+        }
+        upstream := endpoint
+        upstream.URL = upstreamURL
+        result = checkBlockchainRPC(ctx, upstream, config)
+        result.Upstream = upstreamLabel(i)
+        if result.Healthy || result.Cancelled {
+            break
+        }
+    }
+    return result
+}
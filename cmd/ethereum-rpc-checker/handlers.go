@@ -0,0 +1,372 @@
+package main
+
+import (
+    "bytes"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "math/big"
+    "reflect"
+    "strings"
+    "time"
+)
+
+// Result types select how an RPC result is decoded and which metrics it feeds.
+const (
+    // resultTypeBlockNumber is a hex quantity reported as the block number.
+    // It is the default for the main method so any method returning a height works.
+    resultTypeBlockNumber = "block_number"
+    // resultTypeNone isn't decoded; the call only has to succeed.
+    resultTypeNone = "none"
+    // resultTypeSyncing is the false-or-object result of eth_syncing.
+    resultTypeSyncing = "syncing"
+    // resultTypePeerCount is the hex quantity returned by net_peerCount.
+    resultTypePeerCount = "peer_count"
+    // resultTypeGasPrice is the wei quantity returned by eth_gasPrice.
+    resultTypeGasPrice = "gas_price"
+    // resultTypeChainID is the hex quantity returned by eth_chainId.
+    resultTypeChainID = "chain_id"
+    // resultTypeNetVersion is the decimal string returned by net_version.
+    resultTypeNetVersion = "net_version"
+    // resultTypeTxPool is the pending and queued counts returned by txpool_status.
+    resultTypeTxPool = "txpool"
+    // resultTypeBlock is the block object returned by eth_getBlockByNumber.
+    resultTypeBlock = "block"
+    // resultTypeData is a hex byte string such as a hash or an address, which
+    // is exposed as a label rather than parsed as a number.
+    resultTypeData = "data"
+)
+
+// resultHandler decodes a raw RPC result into the check result and returns a
+// short summary for the log.
+type resultHandler func(raw json.RawMessage, result *CheckResult) (string, error)
+
+// resultHandlers maps result types other than block_number to their handler.
+var resultHandlers = map[string]resultHandler{
+    resultTypeNone:       handleNone,
+    resultTypeSyncing:    handleSyncing,
+    resultTypePeerCount:  handlePeerCount,
+    resultTypeGasPrice:   handleGasPrice,
+    resultTypeChainID:    handleChainID,
+    resultTypeNetVersion: handleNetVersion,
+    resultTypeTxPool:     handleTxPool,
+    resultTypeBlock:      handleBlock,
+    resultTypeData:       handleData,
+}
+
+// methodResultTypes is the result type of the methods the checker knows.
+var methodResultTypes = map[string]string{
+    "eth_blockNumber": resultTypeBlockNumber,
+    "eth_syncing":     resultTypeSyncing,
+    "net_peerCount":   resultTypePeerCount,
+    "eth_gasPrice":    resultTypeGasPrice,
+    chainIDMethod:     resultTypeChainID,
+    netVersionMethod:  resultTypeNetVersion,
+    txPoolMethod:      resultTypeTxPool,
+    blockMethod:       resultTypeBlock,
+    "eth_coinbase":    resultTypeData,
+}
+
+// resultTypeFor picks the result type of method: an explicit hint wins, then
+// the method's known type, then fallback.
+func resultTypeFor(method, hint, fallback string) string {
+    if hint != "" {
+        return hint
+    }
+    if resultType, ok := methodResultTypes[method]; ok {
+        return resultType
+    }
+    return fallback
+}
+
+// isKnownResultType reports whether resultType can be used as a result_type hint.
+func isKnownResultType(resultType string) bool {
+    _, ok := resultHandlers[resultType]
+    return ok || resultType == resultTypeBlockNumber
+}
+
+// errEmptyResult is returned for a null or empty result of a method that
+// must return a value, which calls for a different fix than a malformed one.
+var errEmptyResult = errors.New("empty result")
+
+// emptyResultTypes are the result types a null or empty result is a valid
+// answer for: none only needs the call to succeed, and data may be "0x",
+// e.g. eth_getCode of an account without code, or null.
+var emptyResultTypes = map[string]bool{resultTypeNone: true, resultTypeData: true}
+
+// isEmptyResult reports whether raw is null, an empty string or "0x".
+func isEmptyResult(raw json.RawMessage) bool {
+    switch string(bytes.TrimSpace(raw)) {
+    case "", "null", `""`, `"0x"`:
+        return true
+    }
+    return false
+}
+
+// checkEmptyResult returns errEmptyResult when raw is empty and resultType
+// needs a value.
+func checkEmptyResult(raw json.RawMessage, resultType string) error {
+    if isEmptyResult(raw) && !emptyResultTypes[resultType] {
+        return fmt.Errorf("%w: got %s", errEmptyResult, bytes.TrimSpace(raw))
+    }
+    return nil
+}
+
+// decodeQuantity decodes a hex quantity result such as a block number.
+func decodeQuantity(raw json.RawMessage) (uint64, error) {
+    var hexStr string
+    if err := json.Unmarshal(raw, &hexStr); err != nil {
+        return 0, fmt.Errorf("expected a hex string, got %s", raw)
+    }
+    return hexToInt(hexStr)
+}
+
+func handleNone(raw json.RawMessage, result *CheckResult) (string, error) {
+    return "ok", nil
+}
+
+// maxDataLabelLength caps the DATA results kept as a label, enough for a
+// 32-byte hash. Longer results such as contract code are cut short.
+const maxDataLabelLength = 66
+
+// handleData decodes a DATA result, a 0x-prefixed hex string of whole bytes.
+func handleData(raw json.RawMessage, result *CheckResult) (string, error) {
+    if string(bytes.TrimSpace(raw)) == "null" {
+        return "no data", nil
+    }
+    var data string
+    if err := json.Unmarshal(raw, &data); err != nil {
+        return "", fmt.Errorf("expected a hex string, got %s", raw)
+    }
+    digits, ok := strings.CutPrefix(data, "0x")
+    if !ok {
+        return "", fmt.Errorf("invalid hex data %q: missing 0x prefix", data)
+    }
+    if _, err := hex.DecodeString(digits); err != nil {
+        return "", fmt.Errorf("invalid hex data %q: %v", data, err)
+    }
+    if len(data) > maxDataLabelLength {
+        data = data[:maxDataLabelLength] + "..."
+    }
+    result.Data = &data
+    return fmt.Sprintf("data %s", data), nil
+}
+
+// syncProgress is the object eth_syncing returns while the node is syncing.
+type syncProgress struct {
+    CurrentBlock string `json:"currentBlock"`
+    HighestBlock string `json:"highestBlock"`
+}
+
+// handleSyncing decodes eth_syncing, which returns false once the node is
+// synced and a progress object while it is still syncing.
+func handleSyncing(raw json.RawMessage, result *CheckResult) (string, error) {
+    var syncing bool
+    if err := json.Unmarshal(raw, &syncing); err == nil {
+        if syncing {
+            return "", fmt.Errorf("unexpected result %s", raw)
+        }
+        result.Syncing = &SyncStatus{}
+        return "synced", nil
+    }
+
+    var progress syncProgress
+    if err := json.Unmarshal(raw, &progress); err != nil {
+        return "", fmt.Errorf("unexpected result %s: %v", raw, err)
+    }
+    current, err := hexToInt(progress.CurrentBlock)
+    if err != nil {
+        return "", fmt.Errorf("invalid currentBlock: %v", err)
+    }
+    highest, err := hexToInt(progress.HighestBlock)
+    if err != nil {
+        return "", fmt.Errorf("invalid highestBlock: %v", err)
+    }
+
+    gap := uint64(0)
+    if highest > current {
+        gap = highest - current
+    }
+    result.Syncing = &SyncStatus{Syncing: true, Gap: gap}
+    return fmt.Sprintf("syncing, %d blocks behind", gap), nil
+}
+
+// handlePeerCount decodes net_peerCount into the peer count gauge.
+func handlePeerCount(raw json.RawMessage, result *CheckResult) (string, error) {
+    peers, err := decodeQuantity(raw)
+    if err != nil {
+        return "", err
+    }
+    result.PeerCount = &peers
+    return fmt.Sprintf("%d peers", peers), nil
+}
+
+// weiPerGwei converts wei quantities to the gwei unit gas prices are quoted in.
+var weiPerGwei = big.NewFloat(1e9)
+
+// decodeBigQuantity decodes a JSON hex quantity that may not fit in 64 bits, such as a wei amount.
+func decodeBigQuantity(raw json.RawMessage) (*big.Int, error) {
+    var hexStr string
+    if err := json.Unmarshal(raw, &hexStr); err != nil {
+        return nil, fmt.Errorf("expected a hex string, got %s", raw)
+    }
+    return hexToBig(hexStr)
+}
+
+// handleGasPrice decodes eth_gasPrice and records it in gwei.
+func handleGasPrice(raw json.RawMessage, result *CheckResult) (string, error) {
+    wei, err := decodeBigQuantity(raw)
+    if err != nil {
+        return "", err
+    }
+    gwei, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), weiPerGwei).Float64()
+    result.GasPriceGwei = &gwei
+    return fmt.Sprintf("gas price %.2f gwei", gwei), nil
+}
+
+// chainIDMethod is called to verify endpoints that set chain_id.
+const chainIDMethod = "eth_chainId"
+
+// handleChainID decodes eth_chainId.
+func handleChainID(raw json.RawMessage, result *CheckResult) (string, error) {
+    chainID, err := decodeBigQuantity(raw)
+    if err != nil {
+        return "", err
+    }
+    result.ChainID = chainID
+    return fmt.Sprintf("chain %s", chainID), nil
+}
+
+// verifyChainID compares the chain ID decoded into result with the one the endpoint expects.
+func verifyChainID(endpoint Endpoint, result CheckResult) error {
+    if result.ChainID == nil {
+        return fmt.Errorf("%s was not called", chainIDMethod)
+    }
+    if !result.ChainID.IsUint64() || result.ChainID.Uint64() != endpoint.ChainID {
+        return fmt.Errorf("expected chain ID %d, got %s", endpoint.ChainID, result.ChainID)
+    }
+    return nil
+}
+
+// netVersionMethod is called to verify endpoints that set net_version.
+const netVersionMethod = "net_version"
+
+// handleNetVersion decodes net_version, the network ID as a decimal string.
+func handleNetVersion(raw json.RawMessage, result *CheckResult) (string, error) {
+    var netVersion string
+    if err := json.Unmarshal(raw, &netVersion); err != nil {
+        return "", fmt.Errorf("expected a string, got %s", raw)
+    }
+    // Kept as a label, so a bogus result can't blow up the series size. It
+    // is cut on a character boundary: invalid UTF-8 makes labels panic
+    netVersion = truncate(netVersion, maxDataLabelLength)
+    result.NetVersion = &netVersion
+    return fmt.Sprintf("network %s", netVersion), nil
+}
+
+// verifyNetVersion compares the net_version result among raws, the results
+// of calls, with the one the endpoint expects, like expect does.
+func verifyNetVersion(endpoint Endpoint, calls []string, raws []json.RawMessage) error {
+    for i, method := range calls {
+        if method == netVersionMethod {
+            return verifyExpected(raws[i], endpoint.NetVersion)
+        }
+    }
+    return fmt.Errorf("%s was not called", netVersionMethod)
+}
+
+// txPoolMethod is called for the transaction pool of endpoints that set txpool.
+const txPoolMethod = "txpool_status"
+
+// txPoolStatus is the result of txpool_status.
+type txPoolStatus struct {
+    Pending string `json:"pending"`
+    Queued  string `json:"queued"`
+}
+
+// handleTxPool decodes the pending and queued transaction counts of txpool_status.
+func handleTxPool(raw json.RawMessage, result *CheckResult) (string, error) {
+    var status txPoolStatus
+    if err := json.Unmarshal(raw, &status); err != nil {
+        return "", fmt.Errorf("unexpected result %s: %v", raw, err)
+    }
+    pending, err := hexToInt(status.Pending)
+    if err != nil {
+        return "", fmt.Errorf("invalid pending: %v", err)
+    }
+    queued, err := hexToInt(status.Queued)
+    if err != nil {
+        return "", fmt.Errorf("invalid queued: %v", err)
+    }
+    result.TxPool = &TxPoolStatus{Pending: pending, Queued: queued}
+    return fmt.Sprintf("txpool %d pending, %d queued", pending, queued), nil
+}
+
+// blockMethod is called for the head block of endpoints that set head_age.
+const blockMethod = "eth_getBlockByNumber"
+
+// blockHeader is the part of the eth_getBlockByNumber result the checker reads.
+type blockHeader struct {
+    Number    string `json:"number"`
+    Timestamp string `json:"timestamp"`
+}
+
+// handleBlock decodes the timestamp of the block returned by eth_getBlockByNumber.
+func handleBlock(raw json.RawMessage, result *CheckResult) (string, error) {
+    var header *blockHeader
+    if err := json.Unmarshal(raw, &header); err != nil {
+        return "", fmt.Errorf("unexpected result %s: %v", raw, err)
+    }
+    if header == nil {
+        return "", fmt.Errorf("block not found")
+    }
+    timestamp, err := hexToInt(header.Timestamp)
+    if err != nil {
+        return "", fmt.Errorf("invalid timestamp: %v", err)
+    }
+    head := time.Unix(int64(timestamp), 0)
+    result.HeadTimestamp = &head
+    return fmt.Sprintf("head %s old", time.Since(head).Round(time.Second)), nil
+}
+
+// verifyExpected compares the main method's raw result with the endpoint's
+// expected value. Strings match exactly or as equal quantities, so "0x1"
+// matches 1; other results match the expected value parsed as JSON.
+func verifyExpected(raw json.RawMessage, expect string) error {
+    var value any
+    if err := json.Unmarshal(raw, &value); err != nil {
+        return fmt.Errorf("unexpected result %s: %v", raw, err)
+    }
+    if s, ok := value.(string); ok {
+        if s == expect || quantitiesEqual(s, expect) {
+            return nil
+        }
+        return fmt.Errorf("expected %q, got %s", expect, raw)
+    }
+
+    var expected any
+    if err := json.Unmarshal([]byte(expect), &expected); err == nil && reflect.DeepEqual(value, expected) {
+        return nil
+    }
+    var compact bytes.Buffer
+    if err := json.Compact(&compact, raw); err == nil && compact.String() == expect {
+        return nil
+    }
+    return fmt.Errorf("expected %s, got %s", expect, raw)
+}
+
+// quantitiesEqual reports whether a and b are the same number, each given in
+// hex with a 0x prefix or in decimal.
+func quantitiesEqual(a, b string) bool {
+    x, okA := parseQuantity(a)
+    y, okB := parseQuantity(b)
+    return okA && okB && x.Cmp(y) == 0
+}
+
+func parseQuantity(s string) (*big.Int, bool) {
+    if hex, ok := strings.CutPrefix(s, "0x"); ok {
+        return new(big.Int).SetString(hex, 16)
+    }
+    return new(big.Int).SetString(s, 10)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+    "encoding/json"
+    "errors"
+    "strings"
+    "testing"
+    "unicode/utf8"
+)
+
+func TestHandleData(t *testing.T) {
+    const blockHash = "0x88e96d4537bea4d9c05d12549907b32561d3bf31f45aae734cdc119f13406cb6"
+
+    tests := []struct {
+        name     string
+        raw      string
+        wantData string
+        wantErr  bool
+    }{
+        {name: "block hash", raw: `"` + blockHash + `"`, wantData: blockHash},
+        {name: "address", raw: `"0x0000000000000000000000000000000000000000"`, wantData: "0x0000000000000000000000000000000000000000"},
+        {name: "empty", raw: `"0x"`, wantData: "0x"},
+        {name: "long data cut short", raw: `"0x` + blockHash[2:] + `00"`, wantData: blockHash + "..."},
+        // A quantity drops its leading zeros, so it isn't whole bytes
+        {name: "quantity", raw: `"0x1b4"`, wantErr: true},
+        {name: "missing prefix", raw: `"88e96d45"`, wantErr: true},
+        {name: "number", raw: `436`, wantErr: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var result CheckResult
+            _, err := handleData(json.RawMessage(tt.raw), &result)
+            if tt.wantErr {
+                if err == nil {
+                    t.Fatalf("handleData(%s) succeeded, want an error", tt.raw)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("handleData(%s) returned error: %v", tt.raw, err)
+            }
+            if result.Data == nil || *result.Data != tt.wantData {
+                t.Errorf("handleData(%s) data = %v, want %s", tt.raw, result.Data, tt.wantData)
+            }
+        })
+    }
+}
+
+func TestDecodeQuantity(t *testing.T) {
+    tests := []struct {
+        name    string
+        raw     string
+        want    uint64
+        wantErr bool
+    }{
+        {name: "quantity", raw: `"0x1b4"`, want: 436},
+        {name: "zero", raw: `"0x0"`, want: 0},
+        // A hash is DATA and doesn't fit a quantity
+        {name: "block hash", raw: `"0x88e96d4537bea4d9c05d12549907b32561d3bf31f45aae734cdc119f13406cb6"`, wantErr: true},
+        {name: "not a string", raw: `436`, wantErr: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := decodeQuantity(json.RawMessage(tt.raw))
+            if tt.wantErr {
+                if err == nil {
+                    t.Fatalf("decodeQuantity(%s) = %d, want an error", tt.raw, got)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("decodeQuantity(%s) returned error: %v", tt.raw, err)
+            }
+            if got != tt.want {
+                t.Errorf("decodeQuantity(%s) = %d, want %d", tt.raw, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestIsEmptyResult(t *testing.T) {
+    tests := []struct {
+        raw  string
+        want bool
+    }{
+        {raw: `null`, want: true},
+        {raw: `"0x"`, want: true},
+        {raw: `""`, want: true},
+        {raw: ` null `, want: true},
+        {raw: `"0x0"`, want: false},
+        {raw: `false`, want: false},
+        {raw: `{}`, want: false},
+    }
+
+    for _, tt := range tests {
+        if got := isEmptyResult(json.RawMessage(tt.raw)); got != tt.want {
+            t.Errorf("isEmptyResult(%s) = %v, want %v", tt.raw, got, tt.want)
+        }
+    }
+}
+
+func TestCheckEmptyResult(t *testing.T) {
+    tests := []struct {
+        name       string
+        raw        string
+        resultType string
+        wantEmpty  bool
+    }{
+        {name: "null block number", raw: `null`, resultType: resultTypeBlockNumber, wantEmpty: true},
+        {name: "0x block number", raw: `"0x"`, resultType: resultTypeBlockNumber, wantEmpty: true},
+        {name: "null block", raw: `null`, resultType: resultTypeBlock, wantEmpty: true},
+        // Methods that only need to succeed or may return no data accept both
+        {name: "null none", raw: `null`, resultType: resultTypeNone},
+        {name: "0x none", raw: `"0x"`, resultType: resultTypeNone},
+        {name: "null data", raw: `null`, resultType: resultTypeData},
+        {name: "0x data", raw: `"0x"`, resultType: resultTypeData},
+        {name: "value", raw: `"0x1b4"`, resultType: resultTypeBlockNumber},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            err := checkEmptyResult(json.RawMessage(tt.raw), tt.resultType)
+            if tt.wantEmpty && !errors.Is(err, errEmptyResult) {
+                t.Errorf("checkEmptyResult(%s, %s) = %v, want errEmptyResult", tt.raw, tt.resultType, err)
+            }
+            if !tt.wantEmpty && err != nil {
+                t.Errorf("checkEmptyResult(%s, %s) = %v, want nil", tt.raw, tt.resultType, err)
+            }
+        })
+    }
+}
+
+func TestHandleNetVersion(t *testing.T) {
+    tests := []struct {
+        name    string
+        raw     string
+        want    string
+        wantErr bool
+    }{
+        {name: "mainnet", raw: `"1"`, want: "1"},
+        {name: "long", raw: `"` + strings.Repeat("1", 100) + `"`, want: strings.Repeat("1", maxDataLabelLength-3) + "..."},
+        // Cutting by bytes would leave half a character, which makes the
+        // label panic
+        {name: "multi-byte", raw: `"1` + strings.Repeat("é", 40) + `"`, want: "1" + strings.Repeat("é", 40)},
+        {name: "long multi-byte", raw: `"1` + strings.Repeat("é", 80) + `"`, want: "1" + strings.Repeat("é", maxDataLabelLength-4) + "..."},
+        {name: "number", raw: `1`, wantErr: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var result CheckResult
+            _, err := handleNetVersion(json.RawMessage(tt.raw), &result)
+            if tt.wantErr {
+                if err == nil {
+                    t.Fatalf("handleNetVersion(%s) succeeded, want an error", tt.raw)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("handleNetVersion(%s) returned error: %v", tt.raw, err)
+            }
+            if result.NetVersion == nil || *result.NetVersion != tt.want {
+                t.Fatalf("handleNetVersion(%s) net version = %v, want %s", tt.raw, result.NetVersion, tt.want)
+            }
+            if !utf8.ValidString(*result.NetVersion) {
+                t.Errorf("handleNetVersion(%s) net version %q is not valid UTF-8", tt.raw, *result.NetVersion)
+            }
+            // The label must be accepted
+            newMetrics(Config{}).netVersionInfo.WithLabelValues("node", *result.NetVersion).Set(1)
+        })
+    }
+}
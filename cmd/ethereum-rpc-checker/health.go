@@ -0,0 +1,39 @@
+package main
+
+import (
+    "fmt"
+    "net/http"
+    "sync/atomic"
+)
+
+// checkerHealth is the checker's own state, reported by /healthz and /readyz.
+// It says nothing about the health of the monitored endpoints.
+type checkerHealth struct {
+    // running is set while the main loop is serving ticks and signals.
+    running atomic.Bool
+    // swept is set once a full sweep of all endpoints has completed.
+    swept atomic.Bool
+    // ready is set when a config is loaded and its first sweep has finished,
+    // and cleared while a reload is in progress.
+    ready atomic.Bool
+}
+
+var selfHealth checkerHealth
+
+// healthzHandler reports liveness: the main loop is running and has completed a sweep.
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+    if !selfHealth.running.Load() || !selfHealth.swept.Load() {
+        http.Error(w, "not running", http.StatusServiceUnavailable)
+        return
+    }
+    fmt.Fprintln(w, "ok")
+}
+
+// readyzHandler reports readiness: the current config is loaded and has been checked once.
+func readyzHandler(w http.ResponseWriter, r *http.Request) {
+    if !selfHealth.ready.Load() {
+        http.Error(w, "not ready", http.StatusServiceUnavailable)
+        return
+    }
+    fmt.Fprintln(w, "ok")
+}
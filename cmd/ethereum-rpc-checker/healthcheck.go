@@ -0,0 +1,107 @@
+package main
+
+import (
+    "context"
+    "crypto/tls"
+    "flag"
+    "fmt"
+    "net"
+    "net/http"
+    "net/url"
+    "os"
+    "strings"
+    "time"
+)
+
+// healthcheckTimeout bounds the -healthcheck probe so a hung checker fails
+// the container healthcheck instead of blocking it.
+const healthcheckTimeout = 5 * time.Second
+
+// defaultHealthcheckAddress is probed when neither -healthcheck-address nor a
+// readable config file gives the address of the metrics server.
+const defaultHealthcheckAddress = "localhost:9090"
+
+var healthcheckAddress = flag.String("healthcheck-address", defaultHealthcheckAddress, "Address or URL of the checker probed by -healthcheck")
+
+// healthcheckTarget returns the address -healthcheck probes and whether the
+// metrics server uses TLS: -healthcheck-address when it is set, else the
+// prometheus address and certificate of the config file when it can be
+// read, else the default address.
+func healthcheckTarget() (string, bool) {
+    if isFlagSet("healthcheck-address") || *configFile == stdinConfig {
+        return *healthcheckAddress, false
+    }
+    config, err := loadConfigFile(*configFile)
+    if err != nil || config.Prometheus.Address == "" {
+        return *healthcheckAddress, false
+    }
+    return config.Prometheus.Address, config.Prometheus.CertFile != ""
+}
+
+// runHealthcheck probes /healthz of a running checker and reports whether it
+// answered 200. address is a host:port, a URL or a unix: socket path, probed
+// over HTTPS when useTLS is set or the URL says so. It backs the -healthcheck
+// flag for container HEALTHCHECKs, so it needs neither a valid config file
+// nor the metrics server.
+func runHealthcheck(address string, useTLS bool) bool {
+    target, transport, err := healthcheckRequest(address, useTLS)
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "❌ Healthcheck of %s failed: %v\n", address, err)
+        return false
+    }
+
+    client := &http.Client{Timeout: healthcheckTimeout, Transport: transport}
+    resp, err := client.Get(target)
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "❌ Healthcheck of %s failed: %v\n", address, err)
+        return false
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        fmt.Fprintf(os.Stderr, "❌ Healthcheck of %s failed: %s\n", address, resp.Status)
+        return false
+    }
+    return true
+}
+
+// healthcheckRequest returns the /healthz URL of address and the transport
+// reaching it. Unix sockets are dialed directly. The certificate of a local
+// checker isn't verified: it is often issued for the name scrapers use
+// rather than localhost, and the probe only asks whether the checker is up.
+func healthcheckRequest(address string, useTLS bool) (string, *http.Transport, error) {
+    transport := http.DefaultTransport.(*http.Transport).Clone()
+    rawURL := address
+    local := false
+    if path, ok := unixSocketPath(address); ok {
+        if path == "" {
+            return "", nil, fmt.Errorf("missing socket path")
+        }
+        // The host only fills the Host header
+        rawURL = "http://localhost"
+        local = true
+        transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+            var dialer net.Dialer
+            return dialer.DialContext(ctx, "unix", path)
+        }
+    } else if !strings.Contains(rawURL, "://") {
+        rawURL = "http://" + rawURL
+    }
+
+    target, err := url.Parse(rawURL)
+    if err != nil {
+        return "", nil, err
+    }
+    // A listen address such as :9090 is probed on the loopback interface
+    if host := target.Hostname(); host == "" || net.ParseIP(host).IsUnspecified() {
+        target.Host = net.JoinHostPort("localhost", target.Port())
+    }
+    if useTLS && target.Scheme == "http" {
+        target.Scheme = "https"
+    }
+    if host := target.Hostname(); host == "localhost" || net.ParseIP(host).IsLoopback() {
+        local = true
+    }
+    transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: local}
+    target.Path = strings.TrimSuffix(target.Path, "/") + "/healthz"
+    return target.String(), transport, nil
+}
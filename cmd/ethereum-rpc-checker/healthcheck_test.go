@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestHealthcheckRequest(t *testing.T) {
+    tests := []struct {
+        address      string
+        useTLS       bool
+        wantURL      string
+        wantInsecure bool
+    }{
+        {address: "localhost:9090", wantURL: "http://localhost:9090/healthz", wantInsecure: true},
+        {address: ":9090", wantURL: "http://localhost:9090/healthz", wantInsecure: true},
+        {address: "0.0.0.0:9090", useTLS: true, wantURL: "https://localhost:9090/healthz", wantInsecure: true},
+        {address: "unix:/run/checker.sock", wantURL: "http://localhost/healthz", wantInsecure: true},
+        {address: "unix:/run/checker.sock", useTLS: true, wantURL: "https://localhost/healthz", wantInsecure: true},
+        {address: "https://checker.example:9090/", wantURL: "https://checker.example:9090/healthz"},
+        {address: "checker.example:9090", useTLS: true, wantURL: "https://checker.example:9090/healthz"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.address, func(t *testing.T) {
+            got, transport, err := healthcheckRequest(tt.address, tt.useTLS)
+            if err != nil {
+                t.Fatalf("healthcheckRequest(%q) returned error: %v", tt.address, err)
+            }
+            if got != tt.wantURL {
+                t.Errorf("healthcheckRequest(%q) URL = %s, want %s", tt.address, got, tt.wantURL)
+            }
+            if insecure := transport.TLSClientConfig.InsecureSkipVerify; insecure != tt.wantInsecure {
+                t.Errorf("healthcheckRequest(%q) InsecureSkipVerify = %v, want %v", tt.address, insecure, tt.wantInsecure)
+            }
+        })
+    }
+}
@@ -3,42 +3,209 @@ package main
 import (
 	"context"
     "crypto/tls"
+    "errors"
     "crypto/x509"
+    "encoding/json"
 	"flag"
 	"fmt"
     "gopkg.in/yaml.v3"
 	"io/ioutil"
-	"log"
+	"log/slog"
+	"math/big"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/ethereum/go-ethereum/rpc"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/gorilla/websocket"
 )
 
 type Config struct {
-    Endpoints  []Endpoint `yaml:"endpoints"`
-    Interval   int        `yaml:"interval"`
-    Method     string     `yaml:"method"`
-    Debug      bool       `yaml:"debug"`
-    Prometheus struct {
-        Address string `yaml:"address"`
+    Endpoints           []Endpoint `yaml:"endpoints"`
+    EndpointsFile       string     `yaml:"endpoints_file"`
+    Interval            Interval   `yaml:"interval"`
+    Method              string     `yaml:"method"`
+    Debug               bool       `yaml:"debug"`
+    MaxConcurrentChecks int        `yaml:"max_concurrent_checks"`
+    Retries             int        `yaml:"retries"`
+    RetryBackoff        Duration   `yaml:"retry_backoff"`
+    StallThreshold      int        `yaml:"stall_threshold"`
+    FailureThreshold    int        `yaml:"failure_threshold"`
+    SuccessThreshold    int        `yaml:"success_threshold"`
+    StartupGrace        Duration   `yaml:"startup_grace"`
+    Jitter              float64    `yaml:"jitter"`
+    DialTimeout         Duration   `yaml:"dial_timeout"`
+    CallTimeout         Duration   `yaml:"call_timeout"`
+    InsecureSkipVerify  bool       `yaml:"insecure_skip_verify"`
+    Redirects           string     `yaml:"redirects"`
+    UnsupportedMethods  string     `yaml:"unsupported_methods"`
+    UserAgent           string     `yaml:"user_agent"`
+    StateFile           string     `yaml:"state_file"`
+    Prometheus          struct {
+        Address        string `yaml:"address"`
+        Path           string `yaml:"path"`
+        DebugEndpoints bool   `yaml:"debug_endpoints"`
+        CertFile       string `yaml:"cert_file"`
+        KeyFile        string `yaml:"key_file"`
+        BasicAuth      struct {
+            Username string `yaml:"username"`
+            Password string `yaml:"password"`
+        } `yaml:"basic_auth"`
     } `yaml:"prometheus"`
+    Slack struct {
+        WebhookURL string `yaml:"webhook_url"`
+    } `yaml:"slack"`
+    Discord struct {
+        WebhookURL string `yaml:"webhook_url"`
+    } `yaml:"discord"`
+    PagerDuty   PagerDutyConfig   `yaml:"pagerduty"`
+    Pushgateway PushgatewayConfig `yaml:"pushgateway"`
+    Transport   TransportConfig   `yaml:"transport"`
+    Tracing     TracingConfig     `yaml:"tracing"`
+    Metrics struct {
+        Namespace      string    `yaml:"namespace"`
+        LatencyBuckets []float64 `yaml:"latency_buckets"`
+        MaxSeries      int       `yaml:"max_series"`
+        // Enabled lists the metric families to register, all when empty
+        Enabled     []string          `yaml:"enabled"`
+        ConstLabels map[string]string `yaml:"const_labels"`
+    } `yaml:"metrics"`
 }
 
 type Endpoint struct {
-	Name string `yaml:"name"`
-	URL  string `yaml:"url"`
+	Name               string          `yaml:"name"`
+	Group              string          `yaml:"group,omitempty"` // endpoints serving the same chain, compared for drift
+	URL                string          `yaml:"url"`
+	// FallbackURLs are tried in order when the check of URL fails
+	FallbackURLs       []string        `yaml:"fallback_urls,omitempty"`
+	Interval           Interval        `yaml:"interval,omitempty"`
+	Method             string          `yaml:"method,omitempty"`
+	Methods            []string        `yaml:"methods,omitempty"`
+	Extract            []Extractor     `yaml:"extract,omitempty"`
+	Balances           []Balance       `yaml:"balances,omitempty"`
+	ResultType         string          `yaml:"result_type,omitempty"`
+	// Expect is the value the main method must return, checked when set
+	Expect             *string         `yaml:"expect,omitempty"`
+	// ChainID is the expected eth_chainId; 0 skips the check
+	ChainID            uint64          `yaml:"chain_id,omitempty"`
+	// NetVersion is the expected net_version; empty skips the check
+	NetVersion         string          `yaml:"net_version,omitempty"`
+	// HeadAge calls eth_getBlockByNumber to report how old the latest block is
+	HeadAge            bool            `yaml:"head_age,omitempty"`
+	// TxPool calls txpool_status to report the pending and queued transactions
+	TxPool             bool            `yaml:"txpool,omitempty"`
+	// TxPoolRequired marks the endpoint unhealthy when txpool_status isn't supported
+	TxPoolRequired     bool            `yaml:"txpool_required,omitempty"`
+	// Subscribe follows newHeads over WebSocket or IPC on top of the interval checks
+	Subscribe          bool            `yaml:"subscribe,omitempty"`
+	Headers            Headers         `yaml:"headers,omitempty"`
+	TLS                EndpointTLS     `yaml:"tls,omitempty"`
+	// TLSServerName overrides the SNI and the name the certificate is verified against
+	TLSServerName      string          `yaml:"tls_server_name,omitempty"`
+	// HostHeader overrides the Host of HTTP requests and WebSocket handshakes
+	HostHeader         string          `yaml:"host_header,omitempty"`
+	InsecureSkipVerify *bool           `yaml:"insecure_skip_verify,omitempty"` // nil inherits the global value
+	Redirects          string          `yaml:"redirects,omitempty"`
+	// UnsupportedMethods overrides the global unsupported_methods policy
+	UnsupportedMethods string          `yaml:"unsupported_methods,omitempty"`
+	// Proxy overrides the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+	Proxy              string          `yaml:"proxy,omitempty"`
+	// UserAgent overrides the global user_agent; a User-Agent in Headers takes precedence
+	UserAgent          string          `yaml:"user_agent,omitempty"`
+	Retries            *int            `yaml:"retries,omitempty"` // nil inherits the global value, 0 disables retries
+	RetryBackoff       Duration        `yaml:"retry_backoff,omitempty"`
+	StallThreshold     int             `yaml:"stall_threshold,omitempty"`
+	// FailureThreshold and SuccessThreshold debounce the reported health; 0 inherits the global value
+	FailureThreshold   int             `yaml:"failure_threshold,omitempty"`
+	SuccessThreshold   int             `yaml:"success_threshold,omitempty"`
+	DialTimeout        Duration        `yaml:"dial_timeout,omitempty"`
+	CallTimeout        Duration        `yaml:"call_timeout,omitempty"`
+	Transport          TransportConfig `yaml:"transport,omitempty"`
+	// LatencySLA marks the endpoint unhealthy when a call takes longer; 0 disables it
+	LatencySLA         Duration        `yaml:"latency_sla,omitempty"`
+	// RateLimit caps the requests per second sent to the endpoint; 0 disables it
+	RateLimit          float64         `yaml:"rate_limit,omitempty"`
+}
+
+// Headers are extra HTTP headers sent with every request to an endpoint.
+// They usually carry API keys, so String redacts the values.
+type Headers map[string]string
+
+func (h Headers) String() string {
+    names := make([]string, 0, len(h))
+    for name := range h {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    for i, name := range names {
+        names[i] = name + "=********"
+    }
+    return strings.Join(names, ", ")
+}
+
+// Interval is a check interval that accepts either a Go duration string
+// such as "30s" or "5m", or a bare integer interpreted as minutes.
+type Interval time.Duration
+
+func (i *Interval) UnmarshalYAML(value *yaml.Node) error {
+    d, err := parseInterval(value.Value)
+    if err != nil {
+        return fmt.Errorf("invalid interval %q: %v", value.Value, err)
+    }
+    *i = Interval(d)
+    return nil
+}
+
+func (i Interval) Duration() time.Duration {
+    return time.Duration(i)
+}
+
+// MarshalYAML writes the interval as a duration string, e.g. for -print-config.
+func (i Interval) MarshalYAML() (interface{}, error) {
+    return i.Duration().String(), nil
+}
+
+// Duration is a Go duration string such as "500ms" or "2s".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+    parsed, err := time.ParseDuration(strings.TrimSpace(value.Value))
+    if err != nil {
+        return fmt.Errorf("invalid duration %q: %v", value.Value, err)
+    }
+    *d = Duration(parsed)
+    return nil
+}
+
+func (d Duration) Duration() time.Duration {
+    return time.Duration(d)
+}
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+    return d.Duration().String(), nil
+}
+
+// parseInterval normalizes both interval forms into a time.Duration.
+func parseInterval(value string) (time.Duration, error) {
+    value = strings.TrimSpace(value)
+    if minutes, err := strconv.Atoi(value); err == nil {
+        return time.Duration(minutes) * time.Minute, nil
+    }
+    return time.ParseDuration(value)
 }
 
 type RPCClient interface {
 	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
 	Close()
 }
 
@@ -50,31 +217,33 @@ func (e *EthRPCClient) CallContext(ctx context.Context, result interface{}, meth
 	return e.client.CallContext(ctx, result, method, args...)
 }
 
+func (e *EthRPCClient) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	return e.client.BatchCallContext(ctx, b)
+}
+
 func (e *EthRPCClient) Close() {
 	e.client.Close()
 }
 
 var (
     debugMode  = flag.Bool("debug", false, "Enable debug mode")
-    configFile = flag.String("config", "config.yaml", "Path to configuration file")
-    rpcHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-        Name: "blockchain_rpc_healthy",
-        Help: "Indicates if the blockchain RPC endpoint is healthy (1 for healthy, 0 for unhealthy).",
-    }, []string{"endpoint"})
-    blockNumber = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-        Name: "blockchain_block_number",
-        Help: "The current block number of the blockchain.",
-    }, []string{"endpoint"})
+    configFile = flag.String("config", "config.yaml", "Path to configuration file, or - to read YAML from standard input")
+    logFormat  = flag.String("log-format", "text", "Log format: text or json")
+    logLevel   = flag.String("log-level", "info", "Log level: debug, info, warn or error")
     rpcDial = dialRPC
+    rpcClients = newClientCache()
+    endpointStates = newStateStore()
 )
 
-func init() {
-	prometheus.MustRegister(rpcHealthy)
-	prometheus.MustRegister(blockNumber)
-}
-
 func main() {
     helpFlag := flag.Bool("help", false, "Display help information")
+    validateFlag := flag.Bool("validate", false, "Validate the configuration file and exit")
+    dryRunFlag := flag.Bool("dry-run", false, "Print the resolved per-endpoint plan and exit without connecting")
+    printConfigFlag := flag.Bool("print-config", false, "Print the effective configuration as YAML, secrets redacted, and exit")
+    versionFlag := flag.Bool("version", false, "Print version information and exit")
+    onceFlag := flag.Bool("once", false, "Check every endpoint once and exit non-zero if any is unhealthy")
+    countFlag := flag.Int("count", 0, "Run this many sweeps, then exit non-zero if any endpoint is unhealthy (0 runs forever)")
+    healthcheckFlag := flag.Bool("healthcheck", false, "Probe /healthz of a running checker and exit non-zero if it isn't healthy")
     flag.Parse()
 
     if *helpFlag {
@@ -82,10 +251,89 @@ func main() {
         os.Exit(0)
     }
 
-    log.Println("🚀 Starting Blockchain RPC Checker...")
+    if *versionFlag {
+        printVersion()
+        os.Exit(0)
+    }
+
+    if *healthcheckFlag {
+        if !runHealthcheck(healthcheckTarget()) {
+            os.Exit(1)
+        }
+        os.Exit(0)
+    }
+
+    if *countFlag < 0 {
+        fmt.Fprintf(os.Stderr, "❌ -count cannot be negative\n")
+        os.Exit(2)
+    }
+    if *onceFlag && *countFlag > 1 {
+        fmt.Fprintf(os.Stderr, "❌ -once runs a single sweep and cannot be combined with -count %d\n", *countFlag)
+        os.Exit(2)
+    }
+
+    if *validateFlag {
+        if _, err := loadConfigFile(*configFile); err != nil {
+            fmt.Fprintf(os.Stderr, "❌ Configuration %s is invalid:\n%v\n", *configFile, err)
+            os.Exit(1)
+        }
+        fmt.Printf("✅ Configuration %s is valid\n", *configFile)
+        os.Exit(0)
+    }
+
+    if *dryRunFlag {
+        config, err := loadConfigFile(*configFile)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "❌ Configuration %s is invalid:\n%v\n", *configFile, err)
+            os.Exit(1)
+        }
+        printPlan(os.Stdout, config)
+        os.Exit(0)
+    }
+
+    if *printConfigFlag {
+        config, err := loadConfigFile(*configFile)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "❌ Configuration %s is invalid:\n%v\n", *configFile, err)
+            os.Exit(1)
+        }
+        config.Debug = *debugMode
+        if err := printConfig(os.Stdout, config); err != nil {
+            fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+            os.Exit(1)
+        }
+        os.Exit(0)
+    }
+
+    // Debug mode implies debug logs unless a level was given explicitly
+    level := *logLevel
+    if *debugMode && !isFlagSet("log-level") {
+        level = "debug"
+    }
+    if err := setupLogging(*logFormat, level); err != nil {
+        fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+        os.Exit(2)
+    }
+
+    if *probeURL != "" {
+        endpoint, err := probeEndpoint(*probeURL, *checkMethod)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+            os.Exit(2)
+        }
+        ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+        healthy := runProbe(ctx, os.Stdout, endpoint, *debugMode)
+        stop()
+        if !healthy {
+            os.Exit(1)
+        }
+        os.Exit(0)
+    }
+
+    slog.Info(fmt.Sprintf("🚀 Starting Blockchain RPC Checker %s...", version), "version", version, "commit", commit)
     config, err := loadConfigFile(*configFile)
     if err != nil {
-        log.Fatalf("❌ Failed to load configuration: %v", err)
+        fatal(fmt.Sprintf("❌ Failed to load configuration: %v", err), "error", err)
     }
 
     
@@ -93,21 +341,153 @@ func main() {
     config.Debug = *debugMode
 
     // Log configuration
-    log.Printf("📁 Loaded configuration:\n%s", safePrettyPrintConfig(config))
+    slog.Info(fmt.Sprintf("📁 Loaded configuration:\n%s", safePrettyPrintConfig(config)))
+    warnInsecureEndpoints(config)
+    warnDuplicateURLs(config)
+
+    useMetrics(newMetrics(config))
+    metrics.recordConfig(config)
+    notifiers.configure(config)
     
-    ticker := time.NewTicker(time.Duration(config.Interval) * time.Minute)
-    defer ticker.Stop()
+    // Cancel the root context on SIGINT/SIGTERM so everything can wind down
+    ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+    defer stop()
+
+    flushTraces, err := setupTracing(ctx, config.Tracing)
+    if err != nil {
+        fatal(fmt.Sprintf("❌ Failed to set up tracing: %v", err), "error", err)
+    }
+
+    setMaxConcurrentChecks(config.MaxConcurrentChecks)
+    loadState(config)
+
+    // -once is the same as -count 1
+    sweeps := *countFlag
+    if *onceFlag && sweeps == 0 {
+        sweeps = 1
+    }
+    if sweeps > 0 {
+        healthy := runSweeps(ctx, config, sweeps)
+        saveState(config)
+        rpcClients.closeAll()
+        flushCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+        if !notifiers.wait(flushCtx) {
+            slog.Warn("⚠️ Timed out waiting for notifications to be sent")
+        }
+        flushTraces(flushCtx)
+        cancel()
+        if !healthy {
+            os.Exit(1)
+        }
+        os.Exit(0)
+    }
+
+    server := &http.Server{
+        Addr:    config.Prometheus.Address,
+        Handler: newMetricsHandler(config),
+    }
+
+    // Listen before the first sweep so systemd is only told we're ready
+    // once the address is bound
+    listener, err := listenMetrics(config.Prometheus.Address)
+    if err != nil {
+        fatal(fmt.Sprintf("❌ Prometheus HTTP server failed: %v", err), "error", err)
+    }
+
+    // Serve right away so probes can see the checker is starting up
     go func() {
-        for range ticker.C {
-            for _, endpoint := range config.Endpoints {
-                checkBlockchainRPC(endpoint, config.Method, config.Debug)
-            }
+        slog.Info(fmt.Sprintf("📊 Starting Prometheus HTTP server on %s", config.Prometheus.Address), "address", config.Prometheus.Address)
+        if err := serveMetrics(server, listener, config); err != nil && err != http.ErrServerClosed {
+            fatal(fmt.Sprintf("❌ Prometheus HTTP server failed: %v", err), "error", err)
         }
     }()
-    
-    http.Handle("/metrics", promhttp.Handler())
-    log.Printf("📊 Starting Prometheus HTTP server on %s\n", config.Prometheus.Address)
-    log.Fatal(http.ListenAndServe(config.Prometheus.Address, nil))
+
+    // Run a first sweep right away so metrics are populated before the first tick
+    endpointStates.schedule(config.Endpoints)
+    runChecks(ctx, config)
+    pushMetrics(ctx, config)
+    selfHealth.swept.Store(true)
+    selfHealth.ready.Store(true)
+
+    // Each endpoint gets its own ticker so slow endpoints don't delay fast ones
+    sched := startScheduler(ctx, config)
+    selfHealth.running.Store(true)
+    sdNotify(sdReady)
+    go runWatchdog(ctx)
+
+    hup := make(chan os.Signal, 1)
+    signal.Notify(hup, syscall.SIGHUP)
+    defer signal.Stop(hup)
+
+    for {
+        select {
+        case <-hup:
+            slog.Info(fmt.Sprintf("🔄 Reloading configuration from %s", *configFile))
+            newConfig, err := reloadConfig(*configFile)
+            if err != nil {
+                slog.Error(fmt.Sprintf("❌ Failed to reload configuration, keeping the previous one: %v", err), "error", err)
+                continue
+            }
+            selfHealth.ready.Store(false)
+            sched.stop(context.Background())
+            applyReload(config, newConfig)
+            config = newConfig
+            metrics.recordConfig(config)
+            slog.Info(fmt.Sprintf("📁 Loaded configuration:\n%s", safePrettyPrintConfig(config)))
+            warnInsecureEndpoints(config)
+            warnDuplicateURLs(config)
+            setMaxConcurrentChecks(config.MaxConcurrentChecks)
+            notifiers.configure(config)
+            endpointStates.schedule(config.Endpoints)
+            runChecks(ctx, config)
+            pushMetrics(ctx, config)
+            sched = startScheduler(ctx, config)
+            selfHealth.ready.Store(true)
+        case <-ctx.Done():
+            slog.Info("🛑 Shutting down...")
+            sdNotify(sdStopping)
+            selfHealth.running.Store(false)
+            selfHealth.ready.Store(false)
+            shutdown(server, sched, config, flushTraces)
+            slog.Info("👋 Shutdown complete")
+            return
+        }
+    }
+}
+
+// shutdownTimeout bounds how long we wait for in-flight checks and HTTP requests on exit.
+const shutdownTimeout = 10 * time.Second
+
+func shutdown(server *http.Server, sched *scheduler, config Config, flushTraces func(context.Context)) {
+    ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+    defer cancel()
+
+    if err := server.Shutdown(ctx); err != nil {
+        slog.Error(fmt.Sprintf("❌ Error shutting down Prometheus HTTP server: %v", err), "error", err)
+    }
+
+    if !sched.stop(ctx) {
+        slog.Warn("⚠️ Timed out waiting for running checks to finish")
+    }
+    saveState(config)
+    // Checks finishing during the stop may have queued notifications
+    if !notifiers.wait(ctx) {
+        slog.Warn("⚠️ Timed out waiting for notifications to be sent")
+    }
+
+    rpcClients.closeAll()
+    flushTraces(ctx)
+}
+
+// isFlagSet reports whether the named flag was given on the command line.
+func isFlagSet(name string) bool {
+    set := false
+    flag.Visit(func(f *flag.Flag) {
+        if f.Name == name {
+            set = true
+        }
+    })
+    return set
 }
 
 func printHelp() {
@@ -115,63 +495,384 @@ func printHelp() {
     fmt.Println("Usage: ethereum-rpc-checker [options]")
     fmt.Println("\nOptions:")
     fmt.Println("  -help\t\t\tDisplay this help message")
-    fmt.Println("  -config string\tPath to configuration file (default \"config.yaml\")")
+    fmt.Println("  -version\t\tPrint version information and exit")
+    fmt.Println("  -config string\tPath to configuration file, or - to read YAML from standard input (default \"config.yaml\")")
     fmt.Println("  -debug\t\tEnable debug mode for verbose output")
+    fmt.Println("  -validate\t\tValidate the configuration file and exit")
+    fmt.Println("  -dry-run\t\tPrint the resolved per-endpoint plan (defaults and overrides applied) and exit without connecting")
+    fmt.Println("  -print-config\t\tPrint the effective configuration as YAML, with secrets redacted, and exit")
+    fmt.Println("  -once\t\t\tCheck every endpoint once, print a summary and exit (1 if any is unhealthy)")
+    fmt.Println("  -count int\t\tRun this many sweeps, one per interval, print a summary and exit (1 if any is unhealthy in the last one); 0, the default, runs forever")
+    fmt.Println("  -healthcheck\t\tProbe /healthz of a running checker and exit (1 if it isn't healthy)")
+    fmt.Println("  -url string\t\tCall -check-method on this endpoint once, print the result and latency and exit (1 if it fails), without a config file")
+    fmt.Println("  -check-method string\tRPC method called by -url (default \"eth_blockNumber\")")
+    fmt.Println("  -healthcheck-address string\tAddress, URL or unix: socket probed by -healthcheck (default: prometheus.address of -config, else \"localhost:9090\")")
+    fmt.Println("  -log-format string\tLog format: text or json (default \"text\")")
+    fmt.Println("  -log-level string\tLog level: debug, info, warn or error (default \"info\", \"debug\" with -debug)")
+    fmt.Println("  -interval string\tOverride the check interval from the config file")
+    fmt.Println("  -method string\tOverride the RPC method from the config file")
+    fmt.Println("  -prometheus-address string\tOverride the Prometheus listen address from the config file")
+    fmt.Println("  -endpoint name=url\tAdd an endpoint, or replace the one with the same name (repeatable)")
+    fmt.Println("\nOverrides:")
+    fmt.Println("  Flags take precedence over environment variables, which take precedence over the config file.")
+    fmt.Println("  RPC_CHECKER_INTERVAL, RPC_CHECKER_METHOD and RPC_CHECKER_PROMETHEUS_ADDRESS override")
+    fmt.Println("  the interval, method and prometheus.address settings.")
     fmt.Println("\nDescription:")
     fmt.Println("  This tool checks the health of blockchain RPC endpoints and exposes metrics for Prometheus.")
     fmt.Println("  It reads configuration from a YAML file and periodically checks the specified endpoints.")
+    fmt.Println("  Send SIGHUP to reload the configuration file without restarting.")
     fmt.Println("\nConfiguration File Format:")
     fmt.Println("  endpoints:")
     fmt.Println("    - name: endpoint1")
-    fmt.Println("      url: http://example1.com")
+    fmt.Println("      url: http://example1.com  # http(s)://, ws(s):// or ipc:///path/to/geth.ipc")
     fmt.Println("    - name: endpoint2")
     fmt.Println("      url: http://example2.com")
-    fmt.Println("  interval: 5  # Check interval in minutes")
+    fmt.Println("      fallback_urls: [https://backup.example.com]  # Optional URLs tried in order when url fails")
+    fmt.Println("      group: mainnet  # Optional group of endpoints on the same chain, compared for block drift")
+    fmt.Println("      interval: 30s  # Optional per-endpoint interval overriding the global one")
+    fmt.Println("      method: eth_chainId  # Optional per-endpoint RPC method overriding the global one")
+    fmt.Println("      chain_id: 1  # Optional expected chain ID, checked with eth_chainId")
+    fmt.Println("      net_version: \"1\"  # Optional expected network ID, checked with net_version")
+    fmt.Println("      head_age: true  # Optional, reports the age of the latest block from eth_getBlockByNumber")
+    fmt.Println("      txpool: true  # Optional, reports the pending and queued transactions from txpool_status")
+    fmt.Println("      txpool_required: true  # Optional, an endpoint not supporting txpool_status is unhealthy instead of skipping it")
+    fmt.Println("      subscribe: true  # Optional, tracks the block number with eth_subscribe(newHeads) on ws(s):// and ipc endpoints")
+    fmt.Println("      latency_sla: 500ms  # Optional, slower calls mark the endpoint unhealthy")
+    fmt.Println("      rate_limit: 2  # Optional cap on requests per second, retries included")
+    fmt.Println("      result_type: none  # Optional decoding of the method's result: block_number, syncing, peer_count, gas_price, chain_id, net_version, txpool, block, data or none")
+    fmt.Println("      expect: \"1\"  # Optional value the method must return, e.g. for net_version")
+    fmt.Println("      methods: [net_peerCount, eth_syncing]  # Optional extra methods sent in the same batch")
+    fmt.Println("      extract:  # Optional gauges read from the results of any method")
+    fmt.Println("        - method: eth_syncing")
+    fmt.Println("          path: result.currentBlock")
+    fmt.Println("          format: hex  # hex (default) or decimal")
+    fmt.Println("          metric: sync_current_block")
+    fmt.Println("      proxy: socks5://proxy:1080  # Optional http:// or socks5:// proxy (default: HTTP_PROXY, HTTPS_PROXY and NO_PROXY)")
+    fmt.Println("      balances:  # Optional accounts whose balance is read with eth_getBalance")
+    fmt.Println("        - address: \"0x0000000000000000000000000000000000000000\"")
+    fmt.Println("          block: latest  # Block tag or hex number (default: latest)")
+    fmt.Println("          min_wei: \"1000000000000000000\"  # Optional, lower balances are reported as low")
+    fmt.Println("          unhealthy_when_low: true  # Optional, a low balance marks the endpoint unhealthy")
+    fmt.Println("      tls:  # Optional client certificate for mTLS and CA bundle")
+    fmt.Println("        cert_file: client.pem")
+    fmt.Println("        key_file: client-key.pem")
+    fmt.Println("        ca_file: ca.pem  # Trusted instead of the system roots")
+    fmt.Println("      tls_server_name: node.internal  # Optional SNI and certificate name (default: the URL's host)")
+    fmt.Println("      host_header: node.internal  # Optional Host header, e.g. behind a shared ingress (default: the URL's host)")
+    fmt.Println("      headers:  # Optional HTTP headers sent with every request")
+    fmt.Println("        Authorization: Bearer ${API_TOKEN}  # ${VAR} and $VAR are read from the environment, $$ is a literal $")
+    fmt.Println("  state_file: /var/lib/ethereum-rpc-checker/state.json  # Optional file keeping failure counters and last successes across restarts")
+    fmt.Println("  endpoints_file: endpoints.d/*.yaml  # Optional glob of files with more endpoints, relative to this file")
+    fmt.Println("  interval: 5m  # Check interval as a duration (a bare number is read as minutes)")
     fmt.Println("  method: eth_blockNumber  # RPC method to call")
     fmt.Println("  debug: false  # Set to true to enable debug mode (can also be set via command line)")
+    fmt.Println("  stall_threshold: 3  # Checks without a new block before flagging a stall, 0 disables (per endpoint too)")
+    fmt.Println("  failure_threshold: 3  # Failed checks in a row before reporting an endpoint unhealthy (default: 1, per endpoint too)")
+    fmt.Println("  success_threshold: 2  # Successful checks in a row before reporting it healthy again (default: 1, per endpoint too)")
+    fmt.Println("  startup_grace: 1m  # Don't notify health changes of an endpoint this long after it is scheduled, at startup or on reload")
+    fmt.Println("  jitter: 0.1  # Randomize each check time by up to this fraction of the interval")
+    fmt.Println("  max_concurrent_checks: 10  # Maximum number of endpoint checks running at once")
+    fmt.Println("  retries: 2  # Retries for transient failures before marking an endpoint unhealthy (per endpoint too)")
+    fmt.Println("  retry_backoff: 500ms  # Initial retry delay, doubled on each attempt with jitter (per endpoint too)")
+    fmt.Println("  dial_timeout: 30s  # Timeout for connecting to an endpoint (per endpoint too)")
+    fmt.Println("  call_timeout: 30s  # Timeout for a whole check including retries (per endpoint too)")
+    fmt.Println("  transport:  # Optional HTTP connection tuning (per endpoint too)")
+    fmt.Println("    max_idle_conns_per_host: 100")
+    fmt.Println("    idle_conn_timeout: 90s")
+    fmt.Println("    tls_handshake_timeout: 10s")
+    fmt.Println("    keep_alive: 30s")
+    fmt.Println("    max_response_size: 5242880  # Larger responses are rejected, in bytes")
+    fmt.Println("  insecure_skip_verify: false  # Skip TLS certificate verification, for self-signed dev nodes only (per endpoint too)")
+    fmt.Println("  redirects: same_host  # HTTP redirects: follow, same_host or reject (per endpoint too)")
+    fmt.Println("  unsupported_methods: skip  # Additional methods the provider doesn't support: skip or unhealthy (per endpoint too)")
+    fmt.Println("  user_agent: my-checker/1.0  # User-Agent of HTTP and WebSocket requests (default: ethereum-rpc-checker/<version>, per endpoint too)")
     fmt.Println("  prometheus:")
-    fmt.Println("    address: :8080  # Address to expose Prometheus metrics")
+    fmt.Println("    address: :8080  # Address to expose Prometheus metrics, or unix:/path/to.sock for a Unix socket")
+    fmt.Println("    path: /metrics  # Path metrics are served on")
+    fmt.Println("    debug_endpoints: false  # Serve pprof under /debug/pprof/ and expvar under /debug/vars")
+    fmt.Println("    cert_file: server.pem  # Optional certificate and key to serve over HTTPS")
+    fmt.Println("    key_file: server-key.pem")
+    fmt.Println("    basic_auth:  # Optional credentials required for /metrics and /debug/")
+    fmt.Println("      username: prometheus")
+    fmt.Println("      password: ${METRICS_PASSWORD}")
+    fmt.Println("  slack:")
+    fmt.Println("    webhook_url: https://hooks.slack.com/services/...  # Optional webhook notified when an endpoint's health changes")
+    fmt.Println("  discord:")
+    fmt.Println("    webhook_url: https://discord.com/api/webhooks/...  # Optional webhook notified when an endpoint's health changes")
+    fmt.Println("  pagerduty:  # Optional PagerDuty service with incidents opened and resolved as endpoints go down and recover")
+    fmt.Println("    routing_key: ${PAGERDUTY_ROUTING_KEY}  # Integration key of an Events API v2 integration")
+    fmt.Println("    severity: critical  # critical, error, warning or info")
+    fmt.Println("  pushgateway:  # Optional Pushgateway receiving the metrics after every sweep, e.g. for cron jobs")
+    fmt.Println("    url: http://pushgateway:9091")
+    fmt.Println("    job: ethereum-rpc-checker")
+    fmt.Println("    grouping:  # Labels keeping pushes of several checkers apart (default: instance=<hostname>)")
+    fmt.Println("      instance: checker-1")
+    fmt.Println("  tracing:  # Optional OpenTelemetry tracing, one span per check")
+    fmt.Println("    endpoint: http://otel-collector:4318  # OTLP/HTTP collector receiving the spans")
+    fmt.Println("    service_name: ethereum-rpc-checker")
+    fmt.Println("    propagate: false  # Send the trace context to HTTP endpoints in the traceparent header")
+    fmt.Println("  metrics:")
+    fmt.Println("    namespace: blockchain  # Prefix of all metric names")
+    fmt.Println("    latency_buckets: [0.05, 0.1, 0.5, 1]  # Optional RPC latency histogram buckets in seconds")
+    fmt.Println("    max_series: 10000  # Optional cap on the number of labeled series")
+    fmt.Println("    enabled: [rpc_healthy, block_number]  # Optional metric families to export, without the namespace; all by default")
+    fmt.Println("    const_labels: {env: prod, region: eu-west-1}  # Optional labels added to every metric")
 }
 
 const maxConfigDepth = 10
 
+// stdinConfig is the -config value that reads the YAML from standard input.
+const stdinConfig = "-"
+
+// loadConfigFile reads and parses the config file, or standard input for
+// stdinConfig. TOML is picked by the .toml extension.
 func loadConfigFile(filename string) (Config, error) {
+    if filename == stdinConfig {
+        data, err := ioutil.ReadAll(os.Stdin)
+        if err != nil {
+            return Config{}, fmt.Errorf("❌ error reading config from standard input: %v", err)
+        }
+        if len(strings.TrimSpace(string(data))) == 0 {
+            return Config{}, fmt.Errorf("❌ no config on standard input")
+        }
+        // Relative endpoints_file patterns are resolved against the working directory
+        return loadConfig(data, ".", false)
+    }
     data, err := ioutil.ReadFile(filename)
     if err != nil {
         return Config{}, fmt.Errorf("❌ error reading config file: %v", err)
     }
-    return loadConfig(data)
+    return loadConfig(data, filepath.Dir(filename), isTOML(filename))
 }
 
-func loadConfig(data []byte) (Config, error) {
+// loadConfig parses a config file, in TOML rather than YAML when isTOML is
+// set. dir is the directory of the file, which relative endpoints_file
+// patterns are resolved against.
+func loadConfig(data []byte, dir string, isTOML bool) (Config, error) {
+    expanded, err := expandEnv(string(data))
+    if err != nil {
+        return Config{}, fmt.Errorf("❌ error expanding config file: %v", err)
+    }
+    if isTOML {
+        expanded, err = tomlToYAML(expanded)
+        if err != nil {
+            return Config{}, fmt.Errorf("❌ error parsing config file: %v", err)
+        }
+    }
+
     var config Config
-    dec := yaml.NewDecoder(strings.NewReader(string(data)))
+    dec := yaml.NewDecoder(strings.NewReader(expanded))
     dec.KnownFields(true)
     
-    err := dec.Decode(&config)
+    err = dec.Decode(&config)
     if err != nil {
         return Config{}, fmt.Errorf("❌ error parsing config file: %v", err)
     }
 
-    if err := validateConfig(&config, 0); err != nil {
+    if config.EndpointsFile != "" {
+        endpoints, err := loadEndpointFiles(config.EndpointsFile, dir)
+        if err != nil {
+            return Config{}, fmt.Errorf("❌ error loading endpoints: %v", err)
+        }
+        config.Endpoints = append(config.Endpoints, endpoints...)
+    }
+
+    if err := applyOverrides(&config); err != nil {
+        return Config{}, err
+    }
+
+    normalizeEndpoints(config.Endpoints)
+
+    if err := validateConfig(config); err != nil {
         return Config{}, err
     }
 
     return config, nil
 }
 
-func validateConfig(config *Config, depth int) error {
-    if depth > maxConfigDepth {
-        return fmt.Errorf("config nesting too deep")
+// expandEnv replaces ${VAR} and $VAR with the value of the environment
+// variable, and $$ with a literal dollar sign. Referencing an unset variable is
+// an error so a missing secret doesn't silently produce an empty value.
+func expandEnv(data string) (string, error) {
+    var missing []string
+    expanded := os.Expand(data, func(name string) string {
+        if name == "$" {
+            return "$"
+        }
+        value, ok := os.LookupEnv(name)
+        if !ok {
+            missing = append(missing, name)
+        }
+        return value
+    })
+    if len(missing) > 0 {
+        return "", fmt.Errorf("unset environment variables: %s", strings.Join(missing, ", "))
+    }
+    return expanded, nil
+}
+
+// validateConfig checks the loaded config for problems without touching the
+// network. All problems found are returned joined together.
+func validateConfig(config Config) error {
+    var problems []error
+
+    if len(config.Endpoints) == 0 {
+        problems = append(problems, fmt.Errorf("at least one endpoint must be configured"))
     }
 
+    names := make(map[string]bool, len(config.Endpoints))
+    inheritGlobal := false
     for _, endpoint := range config.Endpoints {
-        if err := validateEndpoint(&endpoint, depth+1); err != nil {
-            return err
+        if err := validateEndpoint(&endpoint, 1); err != nil {
+            problems = append(problems, err)
+            continue
+        }
+        for i, fallback := range endpoint.FallbackURLs {
+            upstream := endpoint
+            upstream.URL = fallback
+            if err := validateEndpoint(&upstream, 1); err != nil {
+                problems = append(problems, fmt.Errorf("%v (fallback_urls[%d])", err, i))
+            }
+        }
+        if names[endpoint.Name] {
+            problems = append(problems, fmt.Errorf("endpoint %s: duplicate name, names must be unique as they label the metrics", endpoint.Name))
+        }
+        names[endpoint.Name] = true
+        // A zero interval would make the check loop spin or panic, so it is
+        // refused here rather than when the scheduler starts
+        if endpoint.Interval < 0 {
+            problems = append(problems, fmt.Errorf("endpoint %s: interval must be positive, got %s", endpoint.Name, endpoint.Interval.Duration()))
+        } else if resolveEndpoint(config, endpoint).Interval <= 0 {
+            inheritGlobal = true
+        }
+        if resolveEndpoint(config, endpoint).Method == "" {
+            problems = append(problems, fmt.Errorf("endpoint %s: method cannot be empty", endpoint.Name))
+        }
+        if endpoint.ResultType != "" && !isKnownResultType(endpoint.ResultType) {
+            problems = append(problems, fmt.Errorf("endpoint %s: unknown result_type %q", endpoint.Name, endpoint.ResultType))
+        }
+        problems = append(problems, validateExtractors(endpoint)...)
+        problems = append(problems, validateBalances(endpoint)...)
+        if err := endpoint.Transport.validate(); err != nil {
+            problems = append(problems, fmt.Errorf("endpoint %s: %v", endpoint.Name, err))
+        }
+        if endpoint.Subscribe && !supportsSubscriptions(endpoint.URL) {
+            problems = append(problems, fmt.Errorf("endpoint %s: subscribe requires a ws://, wss:// or IPC URL", endpoint.Name))
+        }
+        if err := validateProxy(endpoint.Proxy); err != nil {
+            problems = append(problems, fmt.Errorf("endpoint %s: %v", endpoint.Name, err))
+        }
+        if strings.ContainsAny(endpoint.TLSServerName, ":/ ") {
+            problems = append(problems, fmt.Errorf("endpoint %s: tls_server_name must be a bare host name, got %q", endpoint.Name, endpoint.TLSServerName))
+        }
+        if strings.ContainsAny(endpoint.HostHeader, "/ ") {
+            problems = append(problems, fmt.Errorf("endpoint %s: host_header must be a host with an optional port, got %q", endpoint.Name, endpoint.HostHeader))
+        }
+        if !isRedirectPolicy(endpoint.Redirects) {
+            problems = append(problems, fmt.Errorf("endpoint %s: unknown redirects policy %q, expected follow, same_host or reject", endpoint.Name, endpoint.Redirects))
+        }
+        if !isUnsupportedMethodsPolicy(endpoint.UnsupportedMethods) {
+            problems = append(problems, fmt.Errorf("endpoint %s: unknown unsupported_methods policy %q, expected skip or unhealthy", endpoint.Name, endpoint.UnsupportedMethods))
+        }
+        if endpoint.LatencySLA < 0 {
+            problems = append(problems, fmt.Errorf("endpoint %s: latency_sla cannot be negative", endpoint.Name))
+        }
+        if endpoint.RateLimit < 0 {
+            problems = append(problems, fmt.Errorf("endpoint %s: rate_limit cannot be negative", endpoint.Name))
+        }
+        if resolveEndpoint(config, endpoint).Retry.retries < 0 {
+            problems = append(problems, fmt.Errorf("endpoint %s: retries cannot be negative", endpoint.Name))
+        }
+        if endpoint.FailureThreshold < 0 || endpoint.SuccessThreshold < 0 {
+            problems = append(problems, fmt.Errorf("endpoint %s: failure_threshold and success_threshold cannot be negative", endpoint.Name))
         }
     }
 
-    return nil
+    if err := validateMetricsAddress(config.Prometheus.Address); err != nil {
+        problems = append(problems, err)
+    }
+    problems = append(problems, validateMetricsServer(config)...)
+    problems = append(problems, validatePushgateway(config.Pushgateway)...)
+    if err := validateTracing(config.Tracing); err != nil {
+        problems = append(problems, err)
+    }
+    if ns := config.Metrics.Namespace; ns != "" && !metricNamespacePattern.MatchString(ns) {
+        problems = append(problems, fmt.Errorf("invalid metrics namespace %q: must match %s", ns, metricNamespacePattern))
+    }
+    if config.FailureThreshold < 0 || config.SuccessThreshold < 0 {
+        problems = append(problems, fmt.Errorf("failure_threshold and success_threshold cannot be negative"))
+    }
+    if config.StartupGrace < 0 {
+        problems = append(problems, fmt.Errorf("startup_grace cannot be negative"))
+    }
+    if config.Metrics.MaxSeries < 0 {
+        problems = append(problems, fmt.Errorf("metrics max_series cannot be negative"))
+    }
+    if err := validateEnabledMetrics(config.Metrics.Enabled); err != nil {
+        problems = append(problems, err)
+    }
+    problems = append(problems, validateConstLabels(config.Metrics.ConstLabels)...)
+    if err := config.Transport.validate(); err != nil {
+        problems = append(problems, err)
+    }
+    if !isRedirectPolicy(config.Redirects) {
+        problems = append(problems, fmt.Errorf("unknown redirects policy %q, expected follow, same_host or reject", config.Redirects))
+    }
+    if !isUnsupportedMethodsPolicy(config.UnsupportedMethods) {
+        problems = append(problems, fmt.Errorf("unknown unsupported_methods policy %q, expected skip or unhealthy", config.UnsupportedMethods))
+    }
+    // Pushes also run on the global interval
+    if inheritGlobal || (config.Pushgateway.URL != "" && config.Interval <= 0) {
+        problems = append(problems, fmt.Errorf("interval must be positive, e.g. 5m or 30s, got %s", config.Interval.Duration()))
+    }
+    if config.Jitter < 0 || config.Jitter >= 1 {
+        problems = append(problems, fmt.Errorf("jitter must be at least 0 and less than 1"))
+    }
+    if err := validateWebhookURL("slack", config.Slack.WebhookURL); err != nil {
+        problems = append(problems, err)
+    }
+    if err := validateWebhookURL("discord", config.Discord.WebhookURL); err != nil {
+        problems = append(problems, err)
+    }
+    problems = append(problems, validatePagerDuty(config.PagerDuty)...)
+
+    return errors.Join(problems...)
+}
+
+// supportedSchemes are the URL schemes dialRPC knows, besides ipc.
+var supportedSchemes = map[string]bool{"http": true, "https": true, "ws": true, "wss": true}
+
+// normalizeEndpoints trims the whitespace a copy-pasted URL tends to carry,
+// so it doesn't fail on every check instead of at load time, and names
+// unnamed endpoints after their URL.
+func normalizeEndpoints(endpoints []Endpoint) {
+    for i := range endpoints {
+        endpoints[i].URL = strings.TrimSpace(endpoints[i].URL)
+        for j := range endpoints[i].FallbackURLs {
+            endpoints[i].FallbackURLs[j] = strings.TrimSpace(endpoints[i].FallbackURLs[j])
+        }
+        endpoints[i].Name = strings.TrimSpace(endpoints[i].Name)
+        if endpoints[i].Name == "" {
+            endpoints[i].Name = defaultEndpointName(endpoints[i].URL)
+        }
+    }
+}
+
+// defaultEndpointName derives a name from an endpoint URL: the host and port,
+// or the socket file name of IPC endpoints. It returns "" when the URL doesn't
+// have either, which validation then rejects.
+func defaultEndpointName(rawURL string) string {
+    if path, ok := ipcPath(rawURL); ok {
+        if path == "" {
+            return ""
+        }
+        return filepath.Base(path)
+    }
+    parsedURL, err := url.Parse(rawURL)
+    if err != nil {
+        return ""
+    }
+    return parsedURL.Host
 }
 
 func validateEndpoint(endpoint *Endpoint, depth int) error {
@@ -180,11 +881,33 @@ func validateEndpoint(endpoint *Endpoint, depth int) error {
     }
 
     if endpoint.Name == "" {
-        return fmt.Errorf("endpoint name cannot be empty")
+        return fmt.Errorf("endpoint with URL %q: name cannot be empty", maskSensitiveInfo(endpoint.URL))
     }
 
     if endpoint.URL == "" {
-        return fmt.Errorf("endpoint URL cannot be empty")
+        return fmt.Errorf("endpoint %s: URL cannot be empty", endpoint.Name)
+    }
+
+    if path, ok := ipcPath(endpoint.URL); ok {
+        if path == "" {
+            return fmt.Errorf("endpoint %s: IPC URL must include a socket path", endpoint.Name)
+        }
+        return nil
+    }
+
+    parsedURL, err := url.Parse(endpoint.URL)
+    if err != nil {
+        return fmt.Errorf("endpoint %s: invalid URL: %v", endpoint.Name, err)
+    }
+    if parsedURL.Scheme == "" || parsedURL.Host == "" {
+        return fmt.Errorf("endpoint %s: URL must include a scheme and host", endpoint.Name)
+    }
+    if !supportedSchemes[parsedURL.Scheme] {
+        return fmt.Errorf("endpoint %s: unsupported URL scheme %q, expected http, https, ws, wss or ipc", endpoint.Name, parsedURL.Scheme)
+    }
+
+    if _, _, err := endpoint.TLS.load(); err != nil {
+        return fmt.Errorf("endpoint %s: tls: %v", endpoint.Name, err)
     }
 
     return nil
@@ -223,27 +946,142 @@ func safePrettyPrintConfig(config Config) string {
 
     if config.Debug {
         sb.WriteString("Configuration (Debug Mode):\n")
-        sb.WriteString(fmt.Sprintf("  Interval: %d minutes\n", config.Interval))
+        sb.WriteString(fmt.Sprintf("  Interval: %s\n", config.Interval.Duration()))
         sb.WriteString(fmt.Sprintf("  Method: %s\n", config.Method))
         sb.WriteString(fmt.Sprintf("  Debug: %v\n", config.Debug))
+        sb.WriteString(fmt.Sprintf("  Max Concurrent Checks: %d\n", config.MaxConcurrentChecks))
+        if config.StartupGrace > 0 {
+            sb.WriteString(fmt.Sprintf("  Startup Grace: %s\n", config.StartupGrace.Duration()))
+        }
+        sb.WriteString(fmt.Sprintf("  Retries: %d\n", config.Retries))
         sb.WriteString(fmt.Sprintf("  Prometheus Address: %s\n", config.Prometheus.Address))
+        if config.Slack.WebhookURL != "" {
+            // The webhook URL is itself the secret
+            sb.WriteString("  Slack Webhook: enabled\n")
+        }
+        if config.Discord.WebhookURL != "" {
+            sb.WriteString("  Discord Webhook: enabled\n")
+        }
+        if config.PagerDuty.RoutingKey != "" {
+            sb.WriteString("  PagerDuty: enabled\n")
+        }
+        if config.Pushgateway.URL != "" {
+            sb.WriteString(fmt.Sprintf("  Pushgateway: %s\n", maskSensitiveInfo(config.Pushgateway.URL)))
+        }
+        if config.Tracing.Endpoint != "" {
+            sb.WriteString(fmt.Sprintf("  Tracing: %s (propagate %v)\n", maskSensitiveInfo(config.Tracing.Endpoint), config.Tracing.Propagate))
+        }
         sb.WriteString("  Endpoints:\n")
         for _, endpoint := range config.Endpoints {
             sb.WriteString(fmt.Sprintf("    - Name: %s\n", endpoint.Name))
             sb.WriteString(fmt.Sprintf("      URL: %s\n", maskSensitiveInfo(endpoint.URL)))
+            for _, fallback := range endpoint.FallbackURLs {
+                sb.WriteString(fmt.Sprintf("      Fallback URL: %s\n", maskSensitiveInfo(fallback)))
+            }
+            if endpoint.Group != "" {
+                sb.WriteString(fmt.Sprintf("      Group: %s\n", endpoint.Group))
+            }
+            if endpoint.Proxy != "" {
+                sb.WriteString(fmt.Sprintf("      Proxy: %s\n", maskSensitiveInfo(endpoint.Proxy)))
+            }
+            if endpoint.TLSServerName != "" {
+                sb.WriteString(fmt.Sprintf("      TLS Server Name: %s\n", endpoint.TLSServerName))
+            }
+            if endpoint.HostHeader != "" {
+                sb.WriteString(fmt.Sprintf("      Host Header: %s\n", endpoint.HostHeader))
+            }
+            if endpoint.Interval > 0 {
+                sb.WriteString(fmt.Sprintf("      Interval: %s\n", endpoint.Interval.Duration()))
+            }
+            if endpoint.Method != "" {
+                sb.WriteString(fmt.Sprintf("      Method: %s\n", endpoint.Method))
+            }
+            if endpoint.Retries != nil {
+                sb.WriteString(fmt.Sprintf("      Retries: %d\n", *endpoint.Retries))
+            }
+            if endpoint.LatencySLA > 0 {
+                sb.WriteString(fmt.Sprintf("      Latency SLA: %s\n", endpoint.LatencySLA.Duration()))
+            }
+            if endpoint.RateLimit > 0 {
+                sb.WriteString(fmt.Sprintf("      Rate Limit: %g/s\n", endpoint.RateLimit))
+            }
+            if endpoint.HeadAge {
+                sb.WriteString("      Head Age: enabled\n")
+            }
+            if endpoint.TxPool {
+                sb.WriteString(fmt.Sprintf("      TxPool: enabled (required %v)\n", endpoint.TxPoolRequired))
+            }
+            if endpoint.Subscribe {
+                sb.WriteString("      Subscribe: enabled\n")
+            }
+            if endpoint.ChainID != 0 {
+                sb.WriteString(fmt.Sprintf("      Chain ID: %d\n", endpoint.ChainID))
+            }
+            if endpoint.NetVersion != "" {
+                sb.WriteString(fmt.Sprintf("      Net Version: %s\n", endpoint.NetVersion))
+            }
+            if endpoint.Expect != nil {
+                sb.WriteString(fmt.Sprintf("      Expect: %s\n", *endpoint.Expect))
+            }
+            if endpoint.ResultType != "" {
+                sb.WriteString(fmt.Sprintf("      Result Type: %s\n", endpoint.ResultType))
+            }
+            if len(endpoint.Methods) > 0 {
+                sb.WriteString(fmt.Sprintf("      Methods: %s\n", strings.Join(endpoint.Methods, ", ")))
+            }
+            for _, balance := range endpoint.Balances {
+                sb.WriteString(fmt.Sprintf("      Balance: %s at %s\n", balance.Address, balance.block()))
+            }
+            for _, extractor := range endpoint.Extract {
+                sb.WriteString(fmt.Sprintf("      Extract: %s from %s at %s\n", extractor.Metric, extractor.Method, extractor.Path))
+            }
+            if len(endpoint.Headers) > 0 {
+                sb.WriteString(fmt.Sprintf("      Headers: %s\n", endpoint.Headers))
+            }
         }
         return sb.String()
     } else {
-        return fmt.Sprintf("Interval: %d minutes\nMethod: %s\nNumber of Endpoints: %d",
-            config.Interval, config.Method, len(config.Endpoints))
+        return fmt.Sprintf("Interval: %s\nMethod: %s\nNumber of Endpoints: %d",
+            config.Interval.Duration(), config.Method, len(config.Endpoints))
+    }
+}
+
+// ipcScheme marks an endpoint URL as the path of a node's IPC socket.
+const ipcScheme = "ipc://"
+
+// ipcPath returns the socket path of an IPC endpoint URL, given either as
+// ipc:///path/to/geth.ipc or as a bare file path.
+func ipcPath(rawURL string) (string, bool) {
+    if strings.HasPrefix(rawURL, ipcScheme) {
+        return strings.TrimPrefix(rawURL, ipcScheme), true
+    }
+    if !strings.Contains(rawURL, "://") && (filepath.IsAbs(rawURL) || strings.HasPrefix(rawURL, ".") || strings.HasSuffix(rawURL, ".ipc")) {
+        return rawURL, true
     }
+    return "", false
 }
 
-func dialRPC(ctx context.Context, endpoint string) (RPCClient, error) {
+func dialRPC(ctx context.Context, endpoint Endpoint) (RPCClient, error) {
+    // IPC connections are local and long-lived, so HTTP and TLS settings don't apply
+    if path, ok := ipcPath(endpoint.URL); ok {
+        dialCtx, cancel := context.WithTimeout(ctx, endpoint.DialTimeout.Duration())
+        defer cancel()
+        client, err := rpc.DialIPC(dialCtx, path)
+        if err != nil {
+            return nil, err
+        }
+        return &EthRPCClient{client}, nil
+    }
+
+    clientCerts, roots, err := endpoint.TLS.load()
+    if err != nil {
+        return nil, err
+    }
+
     // Create a custom dialer
     dialer := &net.Dialer{
-        Timeout:   30 * time.Second,
-        KeepAlive: 30 * time.Second,
+        Timeout:   endpoint.DialTimeout.Duration(),
+        KeepAlive: endpoint.Transport.KeepAlive.Duration(),
     }
 
     // Create a custom TLS configuration
@@ -258,9 +1096,15 @@ func dialRPC(ctx context.Context, endpoint string) (RPCClient, error) {
             tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
         },
         InsecureSkipVerify: false,
+        ServerName:         endpoint.TLSServerName,
+        Certificates:       clientCerts,
+        RootCAs:            roots,
         VerifyConnection: func(cs tls.ConnectionState) error {
+            // Recorded before verifying, so an expired certificate shows up too
+            recordCertExpiry(endpoint.Name, cs)
             opts := x509.VerifyOptions{
                 DNSName:       cs.ServerName,
+                Roots:         roots,
                 Intermediates: x509.NewCertPool(),
             }
             for _, cert := range cs.PeerCertificates[1:] {
@@ -270,74 +1114,483 @@ func dialRPC(ctx context.Context, endpoint string) (RPCClient, error) {
             return err
         },
     }
+    if endpoint.InsecureSkipVerify != nil && *endpoint.InsecureSkipVerify {
+        tlsConfig.InsecureSkipVerify = true
+        tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+            recordCertExpiry(endpoint.Name, cs)
+            return nil
+        }
+    }
 
-    // Create a custom transport
-    transport := &http.Transport{
-        DialContext:           dialer.DialContext,
-        TLSClientConfig:       tlsConfig,
-        MaxIdleConnsPerHost:   100,
-        IdleConnTimeout:       90 * time.Second,
-        TLSHandshakeTimeout:   10 * time.Second,
-        ExpectContinueTimeout: 1 * time.Second,
-        ForceAttemptHTTP2:     true,
+    parsedURL, err := url.Parse(endpoint.URL)
+    if err != nil {
+        return nil, err
+    }
+    proxy, err := endpointProxy(endpoint)
+    if err != nil {
+        return nil, err
     }
 
-    // Create a custom client with the new transport
-    httpClient := &http.Client{
-        Transport: transport,
-        Timeout:   30 * time.Second,
+    headers := make(http.Header, len(endpoint.Headers)+1)
+    headers.Set("User-Agent", endpoint.UserAgent)
+    for name, value := range endpoint.Headers {
+        headers.Set(name, value)
     }
+    options := []rpc.ClientOption{rpc.WithHeaders(headers)}
 
-    client, err := rpc.DialHTTPWithClient(endpoint, httpClient)
+    switch parsedURL.Scheme {
+    case "ws", "wss":
+        // The handshake is bounded by ctx, so the check timeout also caps the dial
+        // The WebSocket dialer takes the Host from the handshake headers
+        if endpoint.HostHeader != "" {
+            headers.Set("Host", endpoint.HostHeader)
+        }
+        wsDialer := websocket.Dialer{
+            NetDialContext:   dialer.DialContext,
+            Proxy:            proxy,
+            TLSClientConfig:  tlsConfig,
+            HandshakeTimeout: endpoint.DialTimeout.Duration(),
+        }
+        options = append(options, rpc.WithWebsocketDialer(wsDialer), rpc.WithWebsocketMessageSizeLimit(endpoint.Transport.MaxResponseSize))
+    default:
+        // Create a custom transport
+        transport := &http.Transport{
+            DialContext:           dialer.DialContext,
+            Proxy:                 proxy,
+            TLSClientConfig:       tlsConfig,
+            MaxIdleConnsPerHost:   endpoint.Transport.MaxIdleConnsPerHost,
+            IdleConnTimeout:       endpoint.Transport.IdleConnTimeout.Duration(),
+            TLSHandshakeTimeout:   endpoint.Transport.TLSHandshakeTimeout.Duration(),
+            ExpectContinueTimeout: 1 * time.Second,
+            ForceAttemptHTTP2:     true,
+        }
+
+        var base http.RoundTripper = transport
+        if endpoint.HostHeader != "" {
+            base = &hostHeaderTransport{base: transport, host: endpoint.HostHeader}
+        }
+        limited := &responseLimitTransport{base: base, limit: endpoint.Transport.MaxResponseSize}
+
+        // Create a custom client with the new transport
+        httpClient := &http.Client{
+            Transport:     &rateLimitTransport{base: &tracePropagationTransport{base: limited}, endpoint: endpoint.Name},
+            Timeout:       endpoint.CallTimeout.Duration(),
+            CheckRedirect: checkRedirect(endpoint),
+        }
+
+        options = append(options, rpc.WithHTTPClient(httpClient))
+    }
+
+    client, err := rpc.DialOptions(ctx, endpoint.URL, options...)
     if err != nil {
         return nil, err
     }
     return &EthRPCClient{client}, nil
 }
 
-func checkBlockchainRPC(endpoint Endpoint, method string, debug bool) {
-    logEndpoint := endpoint.Name
-    if debug {
-        logEndpoint = fmt.Sprintf("%s (%s)", endpoint.Name, maskSensitiveInfo(endpoint.URL))
+// runChecks checks every endpoint concurrently and waits for all of them.
+func runChecks(ctx context.Context, config Config) {
+    var wg sync.WaitGroup
+    for _, endpoint := range config.Endpoints {
+        wg.Add(1)
+        go func(endpoint Endpoint) {
+            defer wg.Done()
+            runCheck(ctx, endpoint, config)
+        }(endpoint)
     }
-    log.Printf("🔍 Checking blockchain RPC endpoint: %s with method: %s\n", logEndpoint, method)
-    
-    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    wg.Wait()
+}
+
+// runCheck runs a single endpoint check once a concurrency slot is free and
+// records its metrics.
+func runCheck(ctx context.Context, endpoint Endpoint, config Config) CheckResult {
+    // Don't hammer a provider that asked us to back off
+    if wait := endpointStates.rateLimitedFor(endpoint.Name); wait > 0 {
+        slog.Info(fmt.Sprintf("⏳ Skipping check of %s, rate limited for another %s", endpointLogName(endpoint, config.Debug), wait.Round(time.Second)),
+            "endpoint", endpoint.Name, "retry_after", wait)
+        return CheckResult{Endpoint: endpoint.Name, Cancelled: true}
+    }
+    if !acquireCheckSlot(ctx) {
+        return CheckResult{Endpoint: endpoint.Name, Cancelled: true, Err: ctx.Err()}
+    }
+    defer releaseCheckSlot()
+    metrics.checksInFlight.Inc()
+    start := time.Now()
+    result := checkWithFailover(ctx, endpoint, config)
+    metrics.checksInFlight.Dec()
+    if !result.Cancelled {
+        metrics.observe(metrics.checkDuration, "rpc_check_duration_seconds", time.Since(start).Seconds(), endpoint.Name)
+    }
+    changed := false
+    if !result.Cancelled {
+        resolved := resolveEndpoint(config, endpoint)
+        changed, result.Reported, result.ConsecutiveFailures = endpointStates.observeHealth(endpoint.Name, result.Healthy, resolved.FailureThreshold, resolved.SuccessThreshold)
+        endpointStatuses.record(endpoint, result)
+    }
+    metrics.recordCheckResult(endpoint, config, result)
+    if changed {
+        if endpointStates.inGrace(endpoint.Name, config.StartupGrace.Duration()) {
+            state := "unhealthy"
+            if result.Reported {
+                state = "healthy"
+            }
+            slog.Info(fmt.Sprintf("🌱 %s is now %s, not notified during the startup grace period", endpointLogName(endpoint, config.Debug), state),
+                "endpoint", endpoint.Name, "healthy", result.Reported)
+        } else {
+            notifiers.publish(newStatusChange(result))
+        }
+    }
+    return result
+}
+
+// scheduleChecks checks the endpoint on every tick until ctx is cancelled.
+// The first check is delayed by offset on top of the interval, and every
+// wait is jittered, so endpoints don't all fire at the same moment.
+func scheduleChecks(ctx context.Context, endpoint Endpoint, config Config, offset time.Duration) {
+    interval := resolveEndpoint(config, endpoint).Interval.Duration()
+    timer := time.NewTimer(offset + jitter(interval, config.Jitter))
+    defer timer.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-timer.C:
+            runCheck(ctx, endpoint, config)
+            timer.Reset(jitter(interval, config.Jitter))
+        }
+    }
+}
+
+// Redirect policies of HTTP endpoints.
+const (
+    redirectsFollow   = "follow"
+    redirectsSameHost = "same_host"
+    redirectsReject   = "reject"
+)
+
+func isRedirectPolicy(policy string) bool {
+    return policy == "" || policy == redirectsFollow || policy == redirectsSameHost || policy == redirectsReject
+}
+
+// checkRedirect returns the http.Client CheckRedirect of a redirect policy.
+// Refused redirects are logged, as they usually mean the URL is outdated or
+// requests with credentials would be sent to another host.
+func checkRedirect(endpoint Endpoint) func(req *http.Request, via []*http.Request) error {
+    return func(req *http.Request, via []*http.Request) error {
+        from := via[len(via)-1].URL
+        refuse := func(reason string) error {
+            slog.Warn(fmt.Sprintf("⚠️ Refused redirect of %s to %s: %s", endpoint.Name, maskSensitiveInfo(req.URL.String()), reason),
+                "endpoint", endpoint.Name, "location", maskSensitiveInfo(req.URL.String()), "redirects", endpoint.Redirects)
+            return fmt.Errorf("redirect to %s refused: %s", maskSensitiveInfo(req.URL.String()), reason)
+        }
+        switch endpoint.Redirects {
+        case redirectsReject:
+            return refuse("redirects are rejected")
+        case redirectsSameHost:
+            if req.URL.Host != from.Host {
+                return refuse("cross-host redirects are rejected")
+            }
+        }
+        if len(via) >= 10 {
+            return fmt.Errorf("stopped after 10 redirects")
+        }
+        return nil
+    }
+}
+
+// warnInsecureEndpoints logs a warning for every endpoint whose TLS
+// certificate isn't verified, so it isn't left on unnoticed.
+func warnInsecureEndpoints(config Config) {
+    for _, endpoint := range config.Endpoints {
+        if *resolveEndpoint(config, endpoint).InsecureSkipVerify {
+            slog.Warn(fmt.Sprintf("⚠️ TLS certificate verification is DISABLED for %s, do not use this in production", endpoint.Name),
+                "endpoint", endpoint.Name)
+        }
+    }
+}
+
+// warnDuplicateURLs logs a warning for every URL, primary or fallback,
+// configured under more than one endpoint name, since each name checks it on
+// its own and multiplies the requests sent to the provider. Duplicates may be
+// intended, e.g. to check different methods, so they aren't an error.
+func warnDuplicateURLs(config Config) {
+    owners := make(map[string]string)
+    for _, endpoint := range config.Endpoints {
+        for _, rawURL := range append([]string{endpoint.URL}, endpoint.FallbackURLs...) {
+            key := strings.TrimSuffix(rawURL, "/")
+            owner, seen := owners[key]
+            if !seen {
+                owners[key] = endpoint.Name
+                continue
+            }
+            if owner != endpoint.Name {
+                slog.Warn(fmt.Sprintf("⚠️ %s is configured under both %s and %s, which multiplies the requests sent to it", maskSensitiveInfo(rawURL), owner, endpoint.Name),
+                    "url", maskSensitiveInfo(rawURL), "endpoint", endpoint.Name, "other_endpoint", owner)
+            }
+        }
+    }
+}
+
+// checkBlockchainRPC checks an endpoint once and returns the outcome. It
+// logs what it finds but leaves the metrics to Metrics.recordCheckResult.
+func checkBlockchainRPC(parent context.Context, endpoint Endpoint, config Config) (result CheckResult) {
+    resolved := resolveEndpoint(config, endpoint)
+    endpoint, retry := resolved.Endpoint, resolved.Retry
+    method := endpoint.Method
+    extras := endpointExtraMethods(endpoint, method)
+    resultType := resultTypeFor(method, endpoint.ResultType, resultTypeBlockNumber)
+    callTimeout := endpoint.CallTimeout.Duration()
+    debug := config.Debug
+
+    logEndpoint := endpointLogName(endpoint, debug)
+    calls := append([]string{method}, extras...)
+    calls = append(calls, extractorMethods(endpoint.Extract, calls)...)
+    slog.Info(fmt.Sprintf("🔍 Checking blockchain RPC endpoint: %s with method: %s", logEndpoint, strings.Join(calls, ", ")),
+        "endpoint", endpoint.Name, "method", method, "extra_methods", calls[1:])
+
+    parent, span := startCheckSpan(parent, endpoint, method)
+    defer func() {
+        result.TraceID = spanTraceID(span)
+        endCheckSpan(span, result)
+    }()
+
+    ctx, cancel := context.WithTimeout(parent, callTimeout)
     defer cancel()
 
-    client, err := rpcDial(ctx, endpoint.URL)
+    result = CheckResult{Endpoint: endpoint.Name}
+    var raws, balanceRaws []json.RawMessage
+    err := withRetry(ctx, retry, func() error {
+        client, err := rpcClients.get(ctx, endpoint)
+        if err != nil {
+            return &dialError{err}
+        }
+
+        if err := requestLimiters.wait(ctx, endpoint); err != nil {
+            return err
+        }
+        start := time.Now()
+        raws, err = callMethods(ctx, client, calls, endpointOptionalMethods(endpoint, config, calls[1:]))
+        var waited time.Duration
+        if err == nil && len(endpoint.Balances) > 0 {
+            waitStart := time.Now()
+            err = requestLimiters.wait(ctx, endpoint)
+            waited = time.Since(waitStart)
+            if err == nil {
+                balanceRaws, err = callBalances(ctx, client, endpoint.Balances)
+            }
+        }
+        // Time spent waiting for the rate limit isn't the endpoint's latency
+        latency := time.Since(start) - waited
+        if err != nil && isConnectionError(err) {
+            rpcClients.discardURL(endpoint)
+            return err
+        }
+        if err != nil && isRateLimited(err) {
+            return &rateLimitedError{err: err, retryAfter: endpointStates.rateLimitedFor(endpoint.Name)}
+        }
+        // Only report latency when the endpoint actually answered
+        result.Latency = latency
+        return err
+    }, func(attempt int, err error, delay time.Duration) {
+        slog.Warn(fmt.Sprintf("🔁 Attempt %d/%d on %s failed: %v, retrying in %s", attempt, retry.retries+1, logEndpoint, err, delay.Round(time.Millisecond)),
+            "endpoint", endpoint.Name, "method", method, "error", err, "attempt", attempt)
+    })
+    if parent.Err() != nil {
+        // Shutting down or reloading, which says nothing about the endpoint
+        result.Cancelled = true
+        result.Err = parent.Err()
+        return result
+    }
     if err != nil {
-        log.Printf("❌ Error connecting to blockchain RPC endpoint %s: %v", logEndpoint, err)
-        rpcHealthy.WithLabelValues(endpoint.Name).Set(0)
-        return
+        result.Err = err
+        result.ErrorCategory = classifyError(err)
+        var dialErr *dialError
+        var limited *rateLimitedError
+        if errors.As(err, &limited) {
+            slog.Warn(fmt.Sprintf("🐢 Rate limited by %s, backing off for %s: %v", logEndpoint, limited.retryAfter.Round(time.Second), err),
+                "endpoint", endpoint.Name, "method", method, "error", err, "category", result.ErrorCategory, "retry_after", limited.retryAfter)
+        } else if errors.As(err, &dialErr) {
+            slog.Error(fmt.Sprintf("❌ Error connecting to blockchain RPC endpoint %s: %v", logEndpoint, err),
+                "endpoint", endpoint.Name, "method", method, "error", err, "category", result.ErrorCategory)
+        } else {
+            slog.Error(fmt.Sprintf("❌ Error calling %s on %s: %v", method, logEndpoint, err),
+                "endpoint", endpoint.Name, "method", method, "error", err, "category", result.ErrorCategory, "duration_ms", result.Latency.Milliseconds())
+        }
+        return result
     }
-    defer client.Close()
 
-    var result string
-    err = client.CallContext(ctx, &result, method)
-    if err != nil {
-        log.Printf("❌ Error calling %s on %s: %v", method, logEndpoint, err)
-        rpcHealthy.WithLabelValues(endpoint.Name).Set(0)
-        return
+    for i, m := range calls[1:] {
+        if raws[i+1] == nil {
+            slog.Warn(fmt.Sprintf("⚠️ %s is not supported by %s, skipping it", m, logEndpoint),
+                "endpoint", endpoint.Name, "method", m, "category", errorCategoryNotFound)
+        }
     }
 
+    raw := raws[0]
+    result.Result = raw
     if debug {
-        log.Printf("📡 Raw result from %s: %s\n", logEndpoint, result)
+        slog.Debug(fmt.Sprintf("📡 Raw result from %s: %s", logEndpoint, raw),
+            "endpoint", endpoint.Name, "method", method, "result", string(raw))
+    }
+
+    if len(endpoint.Extract) > 0 {
+        var errs []error
+        result.Extracted, errs = extractValues(endpoint.Extract, calls, raws)
+        for _, err := range errs {
+            slog.Warn(fmt.Sprintf("⚠️ Cannot extract %v from %s", err, logEndpoint),
+                "endpoint", endpoint.Name, "error", err)
+        }
+    }
+
+    if endpoint.Expect != nil {
+        if err := verifyExpected(raw, *endpoint.Expect); err != nil {
+            slog.Error(fmt.Sprintf("❌ Unexpected result of %s from %s: %v", method, logEndpoint, err),
+                "endpoint", endpoint.Name, "method", method, "error", err)
+            result.Err = err
+            result.ErrorCategory = errorCategoryUnexpected
+            return result
+        }
+    }
+
+    if err := checkEmptyResult(raw, resultType); err != nil {
+        slog.Error(fmt.Sprintf("⚪ Empty result of %s from %s: %v", method, logEndpoint, err),
+            "endpoint", endpoint.Name, "method", method, "error", err)
+        result.Err = err
+        result.ErrorCategory = errorCategoryEmpty
+        return result
+    }
+
+    var summaries []string
+    if resultType == resultTypeBlockNumber {
+        result.BlockNumber, err = decodeQuantity(raw)
+        if err != nil {
+            slog.Error(fmt.Sprintf("❌ Error converting hex to int from %s: %v", logEndpoint, err),
+                "endpoint", endpoint.Name, "method", method, "error", err)
+            result.Err = err
+            result.ErrorCategory = errorCategoryDecode
+            return result
+        }
+        result.HasBlockNumber = true
+    } else {
+        summary, err := resultHandlers[resultType](raw, &result)
+        if err != nil {
+            slog.Error(fmt.Sprintf("❌ Error decoding result from %s: %v", logEndpoint, err),
+                "endpoint", endpoint.Name, "method", method, "error", err)
+            result.Err = err
+            result.ErrorCategory = errorCategoryDecode
+            return result
+        }
+        result.Summary = summary
+        summaries = append(summaries, summary)
+    }
+
+    extraSummaries, err := handleExtraResults(extras, raws[1:], &result)
+    if errors.Is(err, errEmptyResult) {
+        slog.Error(fmt.Sprintf("⚪ Empty result from %s: %v", logEndpoint, err),
+            "endpoint", endpoint.Name, "method", method, "error", err)
+        result.Err = err
+        result.ErrorCategory = errorCategoryEmpty
+        return result
     }
-    
-    blockNum, err := hexToInt(result)
     if err != nil {
-        log.Printf("❌ Error converting hex to int from %s: %v", logEndpoint, err)
-        rpcHealthy.WithLabelValues(endpoint.Name).Set(0)
-        return
+        slog.Error(fmt.Sprintf("❌ Error decoding result from %s: %v", logEndpoint, err),
+            "endpoint", endpoint.Name, "method", method, "error", err)
+        result.Err = err
+        result.ErrorCategory = errorCategoryDecode
+        return result
+    }
+    summaries = append(summaries, extraSummaries...)
+
+    if len(endpoint.Balances) > 0 {
+        var balanceSummaries []string
+        result.Balances, balanceSummaries, err = decodeBalances(endpoint.Balances, balanceRaws)
+        if err != nil {
+            slog.Error(fmt.Sprintf("❌ Error decoding result from %s: %v", logEndpoint, err),
+                "endpoint", endpoint.Name, "method", balanceMethod, "error", err)
+            result.Err = err
+            result.ErrorCategory = errorCategoryDecode
+            return result
+        }
+        summaries = append(summaries, balanceSummaries...)
+    }
+
+    if endpoint.ChainID != 0 {
+        if err := verifyChainID(endpoint, result); err != nil {
+            slog.Error(fmt.Sprintf("❌ Wrong chain on %s: %v", logEndpoint, err),
+                "endpoint", endpoint.Name, "expected_chain_id", endpoint.ChainID, "error", err)
+            result.Err = err
+            result.ErrorCategory = errorCategoryChainID
+            return result
+        }
+    }
+
+    if endpoint.NetVersion != "" {
+        if err := verifyNetVersion(endpoint, calls, raws); err != nil {
+            slog.Error(fmt.Sprintf("❌ Wrong network on %s: %v", logEndpoint, err),
+                "endpoint", endpoint.Name, "expected_net_version", endpoint.NetVersion, "error", err)
+            result.Err = err
+            result.ErrorCategory = errorCategoryChainID
+            return result
+        }
     }
 
-    rpcHealthy.WithLabelValues(endpoint.Name).Set(1)
-    blockNumber.WithLabelValues(endpoint.Name).Set(float64(blockNum))
-    log.Printf("✅ Block Number from %s: %d\n", logEndpoint, blockNum)
+    if err := verifyBalances(endpoint.Balances, result.Balances); err != nil {
+        slog.Error(fmt.Sprintf("🪫 Low balance on %s: %v", logEndpoint, err),
+            "endpoint", endpoint.Name, "method", balanceMethod, "error", err)
+        result.Err = err
+        result.ErrorCategory = errorCategoryLowBalance
+        return result
+    }
+
+    if sla := endpoint.LatencySLA.Duration(); sla > 0 && result.Latency > sla {
+        err := fmt.Errorf("latency %s exceeds the SLA of %s", result.Latency.Round(time.Millisecond), sla)
+        slog.Error(fmt.Sprintf("🐌 SLA breach on %s: %v", logEndpoint, err),
+            "endpoint", endpoint.Name, "method", method, "error", err, "duration_ms", result.Latency.Milliseconds(), "latency_sla_ms", sla.Milliseconds())
+        result.Err = err
+        result.ErrorCategory = errorCategoryLatencySLA
+        return result
+    }
+
+    result.Healthy = true
+    if !result.HasBlockNumber {
+        slog.Info(fmt.Sprintf("✅ %s from %s: %s", method, logEndpoint, strings.Join(summaries, ", ")),
+            "endpoint", endpoint.Name, "method", method, "duration_ms", result.Latency.Milliseconds())
+        return result
+    }
+    message := fmt.Sprintf("✅ Block Number from %s: %d", logEndpoint, result.BlockNumber)
+    if len(summaries) > 0 {
+        message += fmt.Sprintf(" (%s)", strings.Join(summaries, ", "))
+    }
+    slog.Info(message,
+        "endpoint", endpoint.Name, "method", method, "block_number", result.BlockNumber, "duration_ms", result.Latency.Milliseconds())
+    return result
 }
 
-func hexToInt(hexStr string) (int64, error) {
+// hexToInt parses a hex quantity such as "0x1b4" into a uint64. Values that
+// don't fit in 64 bits are rejected rather than wrapped.
+func hexToInt(hexStr string) (uint64, error) {
 	hexStr = strings.TrimPrefix(hexStr, "0x")
-	return strconv.ParseInt(hexStr, 16, 64)
+	return strconv.ParseUint(hexStr, 16, 64)
+}
+
+// hexToBig parses a hex quantity that may not fit in 64 bits, such as a wei
+// amount, into a big.Int. Block numbers and timestamps use hexToInt instead.
+func hexToBig(hexStr string) (*big.Int, error) {
+	digits := strings.TrimPrefix(hexStr, "0x")
+	if digits == "" || digits[0] == '-' || digits[0] == '+' {
+		return nil, fmt.Errorf("invalid hex quantity %q", hexStr)
+	}
+	value, ok := new(big.Int).SetString(digits, 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid hex quantity %q", hexStr)
+	}
+	return value, nil
+}
+
+// bigToFloat converts a quantity to a metric value. Prometheus only stores
+// float64, so quantities above 2^53 are rounded to the nearest float64 and
+// lose their lowest digits. Only convert at the metric boundary.
+func bigToFloat(value *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(value).Float64()
+	return f
 }
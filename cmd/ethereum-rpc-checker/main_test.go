@@ -0,0 +1,246 @@
+package main
+
+import (
+    "math"
+    "math/big"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestHexToInt(t *testing.T) {
+    tests := []struct {
+        input   string
+        want    uint64
+        wantErr bool
+    }{
+        {input: "0x0", want: 0},
+        {input: "0x1406f40", want: 21000000},
+        {input: "0xfffffffffffffffe", want: math.MaxUint64 - 1},
+        {input: "0xffffffffffffffff", want: math.MaxUint64},
+        // One past the maximum must be rejected rather than wrapped
+        {input: "0x10000000000000000", wantErr: true},
+        {input: "0xzz", wantErr: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.input, func(t *testing.T) {
+            got, err := hexToInt(tt.input)
+            if tt.wantErr {
+                if err == nil {
+                    t.Fatalf("hexToInt(%q) = %d, want an error", tt.input, got)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("hexToInt(%q) returned error: %v", tt.input, err)
+            }
+            if got != tt.want {
+                t.Errorf("hexToInt(%q) = %d, want %d", tt.input, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestHexToBig(t *testing.T) {
+    tests := []struct {
+        input   string
+        want    string
+        wantErr bool
+    }{
+        {input: "0x0", want: "0"},
+        {input: "0x3b9aca00", want: "1000000000"},
+        // 2^63 and above overflow an int64
+        {input: "0x8000000000000000", want: "9223372036854775808"},
+        {input: "0x10000000000000001", want: "18446744073709551617"},
+        {input: "0xd3c21bcecceda1000000", want: "1000000000000000000000000"},
+        {input: "0x", wantErr: true},
+        {input: "0x-1", wantErr: true},
+        {input: "0xzz", wantErr: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.input, func(t *testing.T) {
+            got, err := hexToBig(tt.input)
+            if tt.wantErr {
+                if err == nil {
+                    t.Fatalf("hexToBig(%q) = %s, want an error", tt.input, got)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("hexToBig(%q) returned error: %v", tt.input, err)
+            }
+            if got.String() != tt.want {
+                t.Errorf("hexToBig(%q) = %s, want %s", tt.input, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestBigToFloat(t *testing.T) {
+    tests := []struct {
+        input string
+        want  float64
+    }{
+        {input: "0", want: 0},
+        {input: "1000000000", want: 1e9},
+        {input: "9223372036854775808", want: math.Ldexp(1, 63)},
+        // Above 2^53 the lowest digits are rounded away
+        {input: "18446744073709551617", want: math.Ldexp(1, 64)},
+        {input: "1000000000000000000000000", want: 1e24},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.input, func(t *testing.T) {
+            value, ok := new(big.Int).SetString(tt.input, 10)
+            if !ok {
+                t.Fatalf("invalid test value %q", tt.input)
+            }
+            if got := bigToFloat(value); got != tt.want {
+                t.Errorf("bigToFloat(%s) = %v, want %v", tt.input, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestExpandEnv(t *testing.T) {
+    t.Setenv("ERC_TEST_KEY", "secret")
+    t.Setenv("ERC_TEST_EMPTY", "")
+
+    tests := []struct {
+        name    string
+        input   string
+        want    string
+        wantErr string
+    }{
+        {name: "braces", input: "url: https://mainnet.infura.io/v3/${ERC_TEST_KEY}", want: "url: https://mainnet.infura.io/v3/secret"},
+        {name: "bare", input: "key: $ERC_TEST_KEY", want: "key: secret"},
+        {name: "set but empty", input: "key: '${ERC_TEST_EMPTY}'", want: "key: ''"},
+        {name: "escaped dollar", input: "password: pa$$word", want: "password: pa$word"},
+        {name: "escaped reference", input: "literal: $${ERC_TEST_KEY}", want: "literal: ${ERC_TEST_KEY}"},
+        {name: "no references", input: "method: eth_blockNumber", want: "method: eth_blockNumber"},
+        {name: "unset", input: "url: ${ERC_TEST_UNSET}", wantErr: "ERC_TEST_UNSET"},
+        {name: "all unset listed", input: "$ERC_TEST_UNSET ${ERC_TEST_KEY} ${ERC_TEST_OTHER}", wantErr: "ERC_TEST_UNSET, ERC_TEST_OTHER"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := expandEnv(tt.input)
+            if tt.wantErr != "" {
+                if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+                    t.Fatalf("expandEnv(%q) error = %v, want it to name %s", tt.input, err, tt.wantErr)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("expandEnv(%q) returned error: %v", tt.input, err)
+            }
+            if got != tt.want {
+                t.Errorf("expandEnv(%q) = %q, want %q", tt.input, got, tt.want)
+            }
+        })
+    }
+}
+
+// validTestConfig returns the smallest config validateConfig accepts.
+func validTestConfig() Config {
+    var config Config
+    config.Interval = Interval(30 * time.Second)
+    config.Method = "eth_blockNumber"
+    config.Prometheus.Address = "localhost:9090"
+    config.Endpoints = []Endpoint{{Name: "node", URL: "http://localhost:8545"}}
+    return config
+}
+
+func TestValidateConfigRejectsNonPositiveInterval(t *testing.T) {
+    tests := []struct {
+        name     string
+        interval Interval
+        // endpointInterval is the interval of the only endpoint
+        endpointInterval Interval
+        wantErr          bool
+    }{
+        {name: "zero", interval: 0, wantErr: true},
+        {name: "negative", interval: Interval(-time.Second), wantErr: true},
+        {name: "negative endpoint interval", interval: Interval(30 * time.Second), endpointInterval: Interval(-time.Second), wantErr: true},
+        {name: "positive", interval: Interval(30 * time.Second)},
+        {name: "endpoint interval only", endpointInterval: Interval(30 * time.Second)},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            // validateConfig runs while loading the config, before the
+            // scheduler creates the tickers a zero interval would make panic
+            config := validTestConfig()
+            config.Interval = tt.interval
+            config.Endpoints[0].Interval = tt.endpointInterval
+            err := validateConfig(config)
+            if !tt.wantErr {
+                if err != nil {
+                    t.Fatalf("validateConfig() returned error: %v", err)
+                }
+                return
+            }
+            if err == nil || !strings.Contains(err.Error(), "interval must be positive") {
+                t.Fatalf("validateConfig() error = %v, want an interval error", err)
+            }
+        })
+    }
+}
+
+func TestValidateConfig(t *testing.T) {
+    tests := []struct {
+        name   string
+        mutate func(config *Config)
+        // wantErrs are the problems reported, one substring each
+        wantErrs []string
+    }{
+        {name: "valid", mutate: func(config *Config) {}},
+        {name: "no endpoints", mutate: func(config *Config) { config.Endpoints = nil }, wantErrs: []string{"at least one endpoint"}},
+        {name: "empty name", mutate: func(config *Config) { config.Endpoints[0].Name = "" }, wantErrs: []string{"name cannot be empty"}},
+        {name: "empty URL", mutate: func(config *Config) { config.Endpoints[0].URL = "" }, wantErrs: []string{"URL cannot be empty"}},
+        {name: "unparseable URL", mutate: func(config *Config) { config.Endpoints[0].URL = "http://[::1" }, wantErrs: []string{"invalid URL"}},
+        {name: "URL without host", mutate: func(config *Config) { config.Endpoints[0].URL = "localhost:8545" }, wantErrs: []string{"scheme and host"}},
+        {name: "unsupported scheme", mutate: func(config *Config) { config.Endpoints[0].URL = "ftp://localhost" }, wantErrs: []string{"unsupported URL scheme"}},
+        {name: "duplicate name", mutate: func(config *Config) { config.Endpoints = append(config.Endpoints, config.Endpoints[0]) }, wantErrs: []string{"duplicate name"}},
+        {name: "empty method", mutate: func(config *Config) { config.Method = "" }, wantErrs: []string{"method cannot be empty"}},
+        {name: "invalid prometheus address", mutate: func(config *Config) { config.Prometheus.Address = "localhost" }, wantErrs: []string{"invalid prometheus address"}},
+        {
+            name: "every problem reported",
+            mutate: func(config *Config) {
+                config.Interval = 0
+                config.Method = ""
+                config.Prometheus.Address = ""
+                config.Endpoints = append(config.Endpoints, Endpoint{Name: "bad", URL: "ftp://localhost"})
+            },
+            wantErrs: []string{"endpoint bad: unsupported URL scheme", "endpoint node: method cannot be empty", "invalid prometheus address", "interval must be positive"},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            config := validTestConfig()
+            tt.mutate(&config)
+            err := validateConfig(config)
+            if len(tt.wantErrs) == 0 {
+                if err != nil {
+                    t.Fatalf("validateConfig() returned error: %v", err)
+                }
+                return
+            }
+            if err == nil {
+                t.Fatalf("validateConfig() succeeded, want %d problems", len(tt.wantErrs))
+            }
+            problems := err.(interface{ Unwrap() []error }).Unwrap()
+            if len(problems) != len(tt.wantErrs) {
+                t.Errorf("validateConfig() reported %d problems, want %d: %v", len(problems), len(tt.wantErrs), err)
+            }
+            for _, want := range tt.wantErrs {
+                if !strings.Contains(err.Error(), want) {
+                    t.Errorf("validateConfig() error = %v, want it to contain %q", err, want)
+                }
+            }
+        })
+    }
+}
@@ -0,0 +1,543 @@
+package main
+
+import (
+    "fmt"
+    "log/slog"
+    "regexp"
+    "runtime"
+    "sort"
+    "strings"
+    "sync/atomic"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/collectors"
+    dto "github.com/prometheus/client_model/go"
+)
+
+// defaultNamespace prefixes the metric names when metrics.namespace isn't set.
+const defaultNamespace = "blockchain"
+
+// metricNamespacePattern is what Prometheus accepts at the start of a metric name.
+var metricNamespacePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// defaultLatencyBuckets covers typical network RPC latencies, from 10ms to 10s.
+var defaultLatencyBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics holds the collectors of the checker, registered against its own
+// registry. They are built from the config so their namespace and buckets
+// can be configured.
+type Metrics struct {
+    registry            *prometheus.Registry
+
+    rpcHealthy          *prometheus.GaugeVec
+    checkSuccess        *prometheus.GaugeVec
+    blockNumber         *prometheus.GaugeVec
+    checksTotal         *prometheus.CounterVec
+    checkFailures       *prometheus.CounterVec
+    blockStalled        *prometheus.GaugeVec
+    nodeSyncing         *prometheus.GaugeVec
+    syncGap             *prometheus.GaugeVec
+    peerCount           *prometheus.GaugeVec
+    gasPrice            *prometheus.GaugeVec
+    txPoolPending       *prometheus.GaugeVec
+    txPoolQueued        *prometheus.GaugeVec
+    chainIDInfo         *prometheus.GaugeVec
+    netVersionInfo      *prometheus.GaugeVec
+    resultInfo          *prometheus.GaugeVec
+    upstream            *prometheus.GaugeVec
+    accountBalance      *prometheus.GaugeVec
+    balanceLow          *prometheus.GaugeVec
+    headAge             *prometheus.GaugeVec
+    sinceLastHead       *prometheus.GaugeVec
+    headSubscribed      *prometheus.GaugeVec
+    consecutiveFailures *prometheus.GaugeVec
+    blockDrift          *prometheus.GaugeVec
+    rpcErrors           *prometheus.CounterVec
+    reconnects          *prometheus.CounterVec
+    lastSuccess         *prometheus.GaugeVec
+    lastCheck           *prometheus.GaugeVec
+    certExpiry          *prometheus.GaugeVec
+    rpcLatency          *prometheus.HistogramVec
+    checkDuration       *prometheus.HistogramVec
+    checksInFlight      prometheus.Gauge
+    configuredEndpoints prometheus.Gauge
+    interval            prometheus.Gauge
+    buildInfo           *prometheus.GaugeVec
+    series              *seriesGuard
+    // disabled holds the metric families left out by metrics.enabled
+    disabled            map[string]bool
+    // extracted holds the gauges of the endpoints' extractors by metric name
+    extracted           map[string]*prometheus.GaugeVec
+}
+
+// metrics is built in main once the config is loaded, and rebuilt on reload
+// when the metrics settings or the extractor metrics change.
+var metrics *Metrics
+
+// servedRegistry is the registry of metrics, which the metrics server reads.
+// Scrapes may still run while a reload swaps it.
+var servedRegistry atomic.Pointer[prometheus.Registry]
+
+// servedGatherer gathers whatever registry is served at the time.
+type servedGatherer struct{}
+
+func (servedGatherer) Gather() ([]*dto.MetricFamily, error) {
+    return servedRegistry.Load().Gather()
+}
+
+// useMetrics makes m the metrics every check updates and the server serves.
+// Checks update metrics without locking, so they must not be running.
+func useMetrics(m *Metrics) {
+    metrics = m
+    servedRegistry.Store(m.registry)
+}
+
+// newMetrics creates the metrics described by config and registers them,
+// along with the Go runtime and process collectors, on a new registry. Every
+// metric carries the metrics.const_labels.
+func newMetrics(config Config) *Metrics {
+    namespace := config.Metrics.Namespace
+    if namespace == "" {
+        namespace = defaultNamespace
+    }
+    buckets := config.Metrics.LatencyBuckets
+    if len(buckets) == 0 {
+        buckets = defaultLatencyBuckets
+    }
+    constLabels := prometheus.Labels(config.Metrics.ConstLabels)
+
+    m := &Metrics{registry: prometheus.NewRegistry(), series: newSeriesGuard(config.Metrics.MaxSeries)}
+    m.rpcHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "rpc_healthy",
+        Help:        "Indicates if the blockchain RPC endpoint is healthy (1 for healthy, 0 for unhealthy).",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.checkSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "rpc_check_success",
+        Help:        "Outcome of the last check of the endpoint alone, before failure_threshold and success_threshold (1 for success, 0 for failure).",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.blockNumber = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "block_number",
+        Help:        "The current block number of the blockchain.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.checksTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+        Namespace:   namespace,
+        Name:        "rpc_checks_total",
+        Help:        "Total number of checks performed against the blockchain RPC endpoint.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.checkFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+        Namespace:   namespace,
+        Name:        "rpc_check_failures_total",
+        Help:        "Total number of failed checks against the blockchain RPC endpoint.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.blockStalled = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "block_stalled",
+        Help:        "Indicates if the block number stopped increasing (1 for stalled, 0 otherwise).",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.nodeSyncing = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "node_syncing",
+        Help:        "Indicates if the node is syncing according to eth_syncing (1 for syncing, 0 for synced).",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.syncGap = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "sync_gap_blocks",
+        Help:        "Number of blocks between the node's current and highest known block while syncing.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.peerCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "peer_count",
+        Help:        "Number of peers the node is connected to according to net_peerCount.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.gasPrice = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "gas_price_gwei",
+        Help:        "Gas price reported by eth_gasPrice in gwei.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.txPoolPending = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "txpool_pending",
+        Help:        "Number of pending transactions in the transaction pool according to txpool_status.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.txPoolQueued = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "txpool_queued",
+        Help:        "Number of queued transactions in the transaction pool according to txpool_status.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.chainIDInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "chain_id_info",
+        Help:        "Chain ID reported by eth_chainId, as a label. Always 1.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint", "chain_id"})
+    m.netVersionInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "net_version_info",
+        Help:        "Network ID reported by net_version, as a label. Always 1.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint", "net_version"})
+    m.resultInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "rpc_result_info",
+        Help:        "DATA result of the method of an endpoint whose result type is data, as a label. Always 1.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint", "method", "value"})
+    m.upstream = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "rpc_upstream",
+        Help:        "Which URL of an endpoint with fallback URLs answered the last successful check, as a label. Always 1.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint", "upstream"})
+    m.accountBalance = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "account_balance_wei",
+        Help:        "Balance of a watched account in wei according to eth_getBalance.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint", "address"})
+    m.balanceLow = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "account_balance_low",
+        Help:        "1 if the balance of a watched account is below its min_wei, 0 otherwise.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint", "address"})
+    m.headAge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "head_age_seconds",
+        Help:        "Seconds between now and the timestamp of the latest block reported by eth_getBlockByNumber.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.sinceLastHead = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "seconds_since_last_head",
+        Help:        "Seconds since the last head received from the newHeads subscription of the endpoint.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.headSubscribed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "head_subscription_active",
+        Help:        "1 while the newHeads subscription of the endpoint is open, 0 while it is down and the block number is polled.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.consecutiveFailures = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "rpc_consecutive_failures",
+        Help:        "Number of consecutive failed checks of the blockchain RPC endpoint, reset on success.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.blockDrift = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "block_drift",
+        Help:        "Number of blocks the endpoint is behind the highest healthy endpoint of its group.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint", "group"})
+    m.rpcErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+        Namespace:   namespace,
+        Name:        "rpc_errors_total",
+        Help:        "Total number of failed checks of the blockchain RPC endpoint by error category.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint", "category"})
+    m.reconnects = prometheus.NewCounterVec(prometheus.CounterOpts{
+        Namespace:   namespace,
+        Name:        "rpc_reconnects_total",
+        Help:        "Total number of attempts to reconnect the dropped WebSocket or IPC connection of the endpoint.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.lastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "rpc_last_success_timestamp_seconds",
+        Help:        "Unix timestamp of the last successful check of the blockchain RPC endpoint.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.lastCheck = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "rpc_last_check_timestamp_seconds",
+        Help:        "Unix timestamp of the last check of the blockchain RPC endpoint, successful or not.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.certExpiry = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "endpoint_cert_expiry_timestamp_seconds",
+        Help:        "Unix timestamp when the TLS certificate presented by the endpoint expires.",
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.rpcLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+        Namespace:   namespace,
+        Name:        "rpc_latency_seconds",
+        Help:        "Latency of the RPC call to the blockchain endpoint in seconds.",
+        Buckets:     buckets,
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.checkDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+        Namespace:   namespace,
+        Name:        "rpc_check_duration_seconds",
+        Help:        "Duration of the whole check of the blockchain endpoint in seconds, including dialing, retries and decoding.",
+        Buckets:     buckets,
+        ConstLabels: constLabels,
+    }, []string{"endpoint"})
+    m.checksInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "rpc_checks_in_flight",
+        Help:        "Number of checks currently running.",
+        ConstLabels: constLabels,
+    })
+    m.configuredEndpoints = prometheus.NewGauge(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "rpc_configured_endpoints",
+        Help:        "Number of endpoints in the loaded configuration.",
+        ConstLabels: constLabels,
+    })
+    m.interval = prometheus.NewGauge(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "rpc_interval_seconds",
+        Help:        "Global check interval of the loaded configuration in seconds.",
+        ConstLabels: constLabels,
+    })
+    m.buildInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace:   namespace,
+        Name:        "rpc_checker_build_info",
+        Help:        "Build information of the running checker. Always 1.",
+        ConstLabels: constLabels,
+    }, []string{"version", "commit", "go_version"})
+
+    // The runtime collectors don't take options, so their labels are added
+    // by wrapping the registry
+    prometheus.WrapRegistererWith(constLabels, m.registry).MustRegister(
+        collectors.NewGoCollector(),
+        collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+    )
+    m.disabled = disabledFamilies(m.families(), config.Metrics.Enabled)
+    for name, family := range m.families() {
+        if !m.disabled[name] {
+            m.registry.MustRegister(family)
+        }
+    }
+    m.buildInfo.WithLabelValues(version, commit, runtime.Version()).Set(1)
+    m.registerExtractors(namespace, constLabels, config.Endpoints)
+    return m
+}
+
+// endpointVecs returns every metric vector labeled by endpoint, by the
+// metric name the series guard tracks it under.
+func (m *Metrics) endpointVecs() map[string]*prometheus.MetricVec {
+    vecs := map[string]*prometheus.MetricVec{
+        "rpc_healthy":                            m.rpcHealthy.MetricVec,
+        "rpc_check_success":                      m.checkSuccess.MetricVec,
+        "block_number":                           m.blockNumber.MetricVec,
+        "rpc_checks_total":                       m.checksTotal.MetricVec,
+        "rpc_check_failures_total":               m.checkFailures.MetricVec,
+        "rpc_last_success_timestamp_seconds":     m.lastSuccess.MetricVec,
+        "rpc_last_check_timestamp_seconds":       m.lastCheck.MetricVec,
+        "endpoint_cert_expiry_timestamp_seconds": m.certExpiry.MetricVec,
+        "block_stalled":                          m.blockStalled.MetricVec,
+        "node_syncing":                           m.nodeSyncing.MetricVec,
+        "sync_gap_blocks":                        m.syncGap.MetricVec,
+        "peer_count":                             m.peerCount.MetricVec,
+        "gas_price_gwei":                         m.gasPrice.MetricVec,
+        "txpool_pending":                         m.txPoolPending.MetricVec,
+        "txpool_queued":                          m.txPoolQueued.MetricVec,
+        "chain_id_info":                          m.chainIDInfo.MetricVec,
+        "net_version_info":                       m.netVersionInfo.MetricVec,
+        "rpc_result_info":                        m.resultInfo.MetricVec,
+        "rpc_upstream":                           m.upstream.MetricVec,
+        "account_balance_wei":                    m.accountBalance.MetricVec,
+        "account_balance_low":                    m.balanceLow.MetricVec,
+        "head_age_seconds":                       m.headAge.MetricVec,
+        "seconds_since_last_head":                m.sinceLastHead.MetricVec,
+        "head_subscription_active":               m.headSubscribed.MetricVec,
+        "rpc_consecutive_failures":               m.consecutiveFailures.MetricVec,
+        "block_drift":                            m.blockDrift.MetricVec,
+        "rpc_errors_total":                       m.rpcErrors.MetricVec,
+        "rpc_reconnects_total":                   m.reconnects.MetricVec,
+        "rpc_latency_seconds":                    m.rpcLatency.MetricVec,
+        "rpc_check_duration_seconds":             m.checkDuration.MetricVec,
+    }
+    for metric, gauge := range m.extracted {
+        vecs[metric] = gauge.MetricVec
+    }
+    return vecs
+}
+
+// families returns the checker's own metric families by name, without the
+// namespace. Extractor gauges aren't included; they are enabled by being
+// configured.
+func (m *Metrics) families() map[string]prometheus.Collector {
+    families := map[string]prometheus.Collector{
+        "rpc_checks_in_flight":     m.checksInFlight,
+        "rpc_checker_build_info":   m.buildInfo,
+        "rpc_configured_endpoints": m.configuredEndpoints,
+        "rpc_interval_seconds":     m.interval,
+    }
+    for name, vec := range m.endpointVecs() {
+        if _, ok := m.extracted[name]; !ok {
+            families[name] = vec
+        }
+    }
+    return families
+}
+
+// recordConfig exports what the loaded config makes the checker do. It is
+// called at startup and on every reload.
+func (m *Metrics) recordConfig(config Config) {
+    m.configuredEndpoints.Set(float64(len(config.Endpoints)))
+    m.interval.Set(config.Interval.Duration().Seconds())
+}
+
+// disabledFamilies returns the families missing from enabled. An empty
+// enabled list keeps every family, as before metrics.enabled existed.
+func disabledFamilies(families map[string]prometheus.Collector, enabled []string) map[string]bool {
+    disabled := make(map[string]bool)
+    if len(enabled) == 0 {
+        return disabled
+    }
+    keep := make(map[string]bool, len(enabled))
+    for _, name := range enabled {
+        keep[name] = true
+    }
+    for name := range families {
+        if !keep[name] {
+            disabled[name] = true
+        }
+    }
+    return disabled
+}
+
+// metricLabelPattern is what Prometheus accepts as a label name. Names
+// starting with __ are reserved on top of that.
+var metricLabelPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// metricLabels are the variable labels of the checker's metrics, which a
+// constant label can't reuse.
+var metricLabels = map[string]bool{
+    "endpoint": true, "group": true, "category": true, "method": true, "value": true, "chain_id": true,
+    "net_version": true, "upstream": true, "address": true, "version": true, "commit": true, "go_version": true,
+}
+
+// validateConstLabels checks the names of metrics.const_labels.
+func validateConstLabels(labels map[string]string) []error {
+    names := make([]string, 0, len(labels))
+    for name := range labels {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    var problems []error
+    for _, name := range names {
+        switch {
+        case !metricLabelPattern.MatchString(name) || strings.HasPrefix(name, "__"):
+            problems = append(problems, fmt.Errorf("invalid metrics const label %q: must match %s and not start with __", name, metricLabelPattern))
+        case metricLabels[name]:
+            problems = append(problems, fmt.Errorf("metrics const label %q is already a label of the checker's metrics", name))
+        }
+    }
+    return problems
+}
+
+// validateEnabledMetrics checks that metrics.enabled only names known
+// families. Building throwaway metrics keeps their names in one place.
+func validateEnabledMetrics(enabled []string) error {
+    if len(enabled) == 0 {
+        return nil
+    }
+    families := newMetrics(Config{}).families()
+    var unknown []string
+    for _, name := range enabled {
+        if _, ok := families[name]; !ok {
+            unknown = append(unknown, name)
+        }
+    }
+    if len(unknown) > 0 {
+        known := make([]string, 0, len(families))
+        for name := range families {
+            known = append(known, name)
+        }
+        sort.Strings(known)
+        return fmt.Errorf("unknown metrics in metrics.enabled: %s (known: %s)", strings.Join(unknown, ", "), strings.Join(known, ", "))
+    }
+    return nil
+}
+
+// configuredMetrics are the metrics whose series follow an endpoint's
+// config rather than its checks, e.g. one series per watched address.
+var configuredMetrics = []string{"rpc_upstream", "account_balance_wei", "account_balance_low", "seconds_since_last_head", "head_subscription_active"}
+
+// resetEndpoint drops every per-endpoint series for an endpoint that is no
+// longer configured. Series are matched on the endpoint label alone, so
+// vectors with further labels, or without any series of the endpoint, are
+// handled alike.
+func (m *Metrics) resetEndpoint(name string) {
+    for _, vec := range m.endpointVecs() {
+        vec.DeletePartialMatch(prometheus.Labels{"endpoint": name})
+    }
+    m.series.forget(name, "")
+}
+
+// resetConfiguredSeries drops the series of a changed endpoint that follow
+// its config, so a removed address, fallback URL, extractor or subscription
+// doesn't leave a stale series behind. The next check recreates the others.
+func (m *Metrics) resetConfiguredSeries(name string) {
+    vecs := m.endpointVecs()
+    for _, metric := range configuredMetrics {
+        m.deleteEndpoint(vecs[metric], metric, name)
+    }
+    for metric, gauge := range m.extracted {
+        m.deleteEndpoint(gauge.MetricVec, metric, name)
+    }
+}
+
+// registerExtractors creates a gauge for every metric named by an extractor.
+// Endpoints naming the same metric share its gauge. A name clashing with
+// another metric is logged and left out rather than stopping the checker.
+func (m *Metrics) registerExtractors(namespace string, constLabels prometheus.Labels, endpoints []Endpoint) {
+    m.extracted = make(map[string]*prometheus.GaugeVec)
+    for _, endpoint := range endpoints {
+        for _, extractor := range endpoint.Extract {
+            if _, ok := m.extracted[extractor.Metric]; ok {
+                continue
+            }
+            gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+                Namespace:   namespace,
+                Name:        extractor.Metric,
+                Help:        fmt.Sprintf("Value extracted from the %s result at %s.", extractor.Method, extractor.Path),
+                ConstLabels: constLabels,
+            }, []string{"endpoint"})
+            if err := m.registry.Register(gauge); err != nil {
+                slog.Error(fmt.Sprintf("❌ Cannot register extractor metric %s: %v", extractor.Metric, err),
+                    "endpoint", endpoint.Name, "metric", extractor.Metric, "error", err)
+                continue
+            }
+            m.extracted[extractor.Metric] = gauge
+        }
+    }
+}
+
+// recordExtracted sets the extractor gauges of an endpoint. Values that
+// couldn't be extracted this time are dropped rather than left stale.
+func (m *Metrics) recordExtracted(endpoint Endpoint, values map[string]float64) {
+    for _, extractor := range endpoint.Extract {
+        gauge, ok := m.extracted[extractor.Metric]
+        if !ok {
+            continue
+        }
+        if value, ok := values[extractor.Metric]; ok {
+            m.set(gauge, extractor.Metric, value, endpoint.Name)
+        } else {
+            gauge.DeleteLabelValues(endpoint.Name)
+            m.series.forget(endpoint.Name, extractor.Metric)
+        }
+    }
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "net/url"
+    "sync"
+    "time"
+    "unicode/utf8"
+)
+
+// webhookTimeout bounds a single webhook post so a slow webhook can't pile up goroutines.
+const webhookTimeout = 10 * time.Second
+
+var webhookClient = &http.Client{}
+
+// StatusChange is an endpoint becoming unhealthy or recovering.
+type StatusChange struct {
+    Endpoint string
+    Healthy  bool
+    // Err is why the endpoint became unhealthy, nil when it recovered. Its
+    // URLs are redacted since the change is posted to third parties.
+    Err  error
+    Time time.Time
+    // BlockNumber is the block the check returned, nil if it didn't return one.
+    BlockNumber *uint64
+}
+
+// newStatusChange describes the transition a check result ended in.
+func newStatusChange(result CheckResult) StatusChange {
+    change := StatusChange{
+        Endpoint: result.Endpoint,
+        Healthy:  result.Reported,
+        Time:     time.Now().UTC(),
+    }
+    if !result.Reported && result.Err != nil {
+        change.Err = errors.New(redactError(result.Err))
+    }
+    if result.HasBlockNumber {
+        block := result.BlockNumber
+        change.BlockNumber = &block
+    }
+    return change
+}
+
+// Notifier posts status changes to an alerting backend. Each backend is a
+// small implementation registered with the notifier registry; transitions
+// are detected once, in runCheck, and fanned out to all of them.
+type Notifier interface {
+    // Name is how the backend appears in logs.
+    Name() string
+    Notify(ctx context.Context, change StatusChange) error
+}
+
+// notifierRegistry fans status changes out to the configured notifiers.
+type notifierRegistry struct {
+    mu        sync.Mutex
+    notifiers []Notifier
+    // inFlight tracks the posts in flight so shutdown can wait for them.
+    inFlight sync.WaitGroup
+}
+
+var notifiers = &notifierRegistry{}
+
+// configure replaces the notifiers with one for every backend set up in
+// config. It runs at startup and on every reload.
+func (r *notifierRegistry) configure(config Config) {
+    var configured []Notifier
+    if config.Slack.WebhookURL != "" {
+        configured = append(configured, slackNotifier{webhookURL: config.Slack.WebhookURL})
+    }
+    if config.Discord.WebhookURL != "" {
+        configured = append(configured, discordNotifier{webhookURL: config.Discord.WebhookURL})
+    }
+    if config.PagerDuty.RoutingKey != "" {
+        configured = append(configured, pagerDutyNotifier{config: config.PagerDuty})
+    }
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.notifiers = configured
+}
+
+// publish hands a status change to every notifier. The posts run in the
+// background, each bounded by webhookTimeout, and failures are only logged.
+func (r *notifierRegistry) publish(change StatusChange) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    for _, n := range r.notifiers {
+        r.inFlight.Add(1)
+        go func(n Notifier) {
+            defer r.inFlight.Done()
+            ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+            defer cancel()
+            if err := n.Notify(ctx, change); err != nil {
+                slog.Warn(fmt.Sprintf("⚠️ Failed to send %s notification for %s: %v", n.Name(), change.Endpoint, err),
+                    "endpoint", change.Endpoint, "notifier", n.Name(), "error", err)
+            }
+        }(n)
+    }
+}
+
+// wait waits for the posts in flight to finish or for ctx to expire,
+// whichever comes first. It reports whether all of them finished.
+func (r *notifierRegistry) wait(ctx context.Context) bool {
+    done := make(chan struct{})
+    go func() {
+        r.inFlight.Wait()
+        close(done)
+    }()
+    select {
+    case <-done:
+        return true
+    case <-ctx.Done():
+        return false
+    }
+}
+
+// postWebhook posts payload as JSON to a webhook. Any non-2xx status is an error.
+func postWebhook(ctx context.Context, webhookURL string, payload any) error {
+    body, err := json.Marshal(payload)
+    if err != nil {
+        return err
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    resp, err := webhookClient.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode >= 300 {
+        return fmt.Errorf("webhook returned %s", resp.Status)
+    }
+    return nil
+}
+
+// validateWebhookURL checks the webhook URL of the named notifier.
+func validateWebhookURL(name, webhookURL string) error {
+    if webhookURL == "" {
+        return nil
+    }
+    if u, err := url.Parse(webhookURL); err != nil || u.Scheme == "" || u.Host == "" {
+        return fmt.Errorf("invalid %s webhook_url", name)
+    }
+    return nil
+}
+
+// truncate cuts s to at most limit characters, ending it with "..." when
+// it is cut. The backends count characters rather than bytes, and cutting
+// inside a multi-byte character would send invalid UTF-8.
+func truncate(s string, limit int) string {
+    if utf8.RuneCountInString(s) <= limit {
+        return s
+    }
+    return string([]rune(s)[:limit-3]) + "..."
+}
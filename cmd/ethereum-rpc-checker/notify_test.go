@@ -0,0 +1,81 @@
+package main
+
+import (
+    "context"
+    "strings"
+    "sync/atomic"
+    "testing"
+    "time"
+    "unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+    tests := []struct {
+        name  string
+        input string
+        limit int
+        want  string
+    }{
+        {name: "short", input: "timeout", limit: 10, want: "timeout"},
+        {name: "at the limit", input: "0123456789", limit: 10, want: "0123456789"},
+        {name: "ascii", input: "0123456789ab", limit: 10, want: "0123456..."},
+        // Each of these characters is several bytes, counted as one
+        {name: "multi-byte", input: strings.Repeat("é", 12), limit: 10, want: strings.Repeat("é", 7) + "..."},
+        {name: "multi-byte at the limit", input: strings.Repeat("🚨", 10), limit: 10, want: strings.Repeat("🚨", 10)},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := truncate(tt.input, tt.limit)
+            if got != tt.want {
+                t.Errorf("truncate(%q, %d) = %q, want %q", tt.input, tt.limit, got, tt.want)
+            }
+            if !utf8.ValidString(got) {
+                t.Errorf("truncate(%q, %d) = %q, not valid UTF-8", tt.input, tt.limit, got)
+            }
+        })
+    }
+}
+
+// blockingNotifier counts the changes it is sent once release is closed.
+type blockingNotifier struct {
+    name    string
+    release chan struct{}
+    sent    *atomic.Int32
+}
+
+func (n blockingNotifier) Name() string { return n.name }
+
+func (n blockingNotifier) Notify(ctx context.Context, change StatusChange) error {
+    <-n.release
+    n.sent.Add(1)
+    return nil
+}
+
+func TestNotifierRegistryWait(t *testing.T) {
+    // Every backend is tracked by the one wait shutdown runs, not only Slack
+    release := make(chan struct{})
+    var sent atomic.Int32
+    r := &notifierRegistry{notifiers: []Notifier{
+        blockingNotifier{name: "Slack", release: release, sent: &sent},
+        blockingNotifier{name: "Discord", release: release, sent: &sent},
+        blockingNotifier{name: "PagerDuty", release: release, sent: &sent},
+    }}
+    r.publish(StatusChange{Endpoint: "node"})
+
+    ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+    defer cancel()
+    if r.wait(ctx) {
+        t.Fatal("wait() returned true while every post is still in flight")
+    }
+
+    close(release)
+    ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    if !r.wait(ctx) {
+        t.Fatal("wait() timed out after the posts were released")
+    }
+    if got := sent.Load(); got != 3 {
+        t.Errorf("wait() returned after %d posts, want 3", got)
+    }
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "sync"
+    "time"
+)
+
+// runSweeps checks every endpoint count times, one sweep per sweepInterval,
+// prints a summary and reports whether all of them were healthy in the last
+// sweep. It backs the -once and -count flags for cron jobs, CI gates and
+// bounded diagnostic runs, so no metrics server is started; the metrics are
+// pushed after each sweep when a Pushgateway is configured. Cancelling ctx
+// stops after the running sweep.
+func runSweeps(ctx context.Context, config Config, count int) bool {
+    var results []CheckResult
+    healthySweeps := make([]int, len(config.Endpoints))
+    sweeps := 0
+    interval := sweepInterval(config)
+sweeps:
+    for sweeps < count {
+        if sweeps > 0 {
+            timer := time.NewTimer(interval)
+            select {
+            case <-timer.C:
+            case <-ctx.Done():
+                timer.Stop()
+                break sweeps
+            }
+        }
+        results = sweep(ctx, config)
+        sweeps++
+        for i, result := range results {
+            if result.Reported {
+                healthySweeps[i]++
+            }
+        }
+        pushMetrics(ctx, config)
+    }
+
+    healthy := 0
+    fmt.Println("\nSummary:")
+    for i, result := range results {
+        var line string
+        switch {
+        case result.Reported:
+            healthy++
+            line = fmt.Sprintf("  ✅ %s: healthy", result.Endpoint)
+        case result.Healthy:
+            // Down in a previous sweep and not yet past success_threshold
+            line = fmt.Sprintf("  ❌ %s: unhealthy: recovering", result.Endpoint)
+        default:
+            line = fmt.Sprintf("  ❌ %s: unhealthy: %v", result.Endpoint, result.Err)
+        }
+        if count > 1 {
+            line += fmt.Sprintf(" (healthy in %d/%d sweeps)", healthySweeps[i], sweeps)
+        }
+        fmt.Println(line)
+    }
+    fmt.Printf("%d/%d endpoints healthy\n", healthy, len(config.Endpoints))
+    return healthy == len(config.Endpoints)
+}
+
+// sweepInterval is the shortest interval of the endpoints, so each is
+// checked at least as often as when running continuously. The global
+// interval may be unset when every endpoint has its own.
+func sweepInterval(config Config) time.Duration {
+    var interval time.Duration
+    for _, endpoint := range config.Endpoints {
+        d := resolveEndpoint(config, endpoint).Interval.Duration()
+        if interval == 0 || d < interval {
+            interval = d
+        }
+    }
+    return interval
+}
+
+// sweep checks every endpoint concurrently and returns the results in the
+// order of the configuration.
+func sweep(ctx context.Context, config Config) []CheckResult {
+    results := make([]CheckResult, len(config.Endpoints))
+    var wg sync.WaitGroup
+    for i, endpoint := range config.Endpoints {
+        wg.Add(1)
+        go func(i int, endpoint Endpoint) {
+            defer wg.Done()
+            results[i] = runCheck(ctx, endpoint, config)
+        }(i, endpoint)
+    }
+    wg.Wait()
+    return results
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "os"
+    "strings"
+)
+
+// Settings can be overridden without editing the config file. Flags take
+// precedence over environment variables, which take precedence over the file.
+var (
+    intervalFlag          = flag.String("interval", "", "Override the check interval from the config file")
+    methodFlag            = flag.String("method", "", "Override the RPC method from the config file")
+    prometheusAddressFlag = flag.String("prometheus-address", "", "Override the Prometheus listen address from the config file")
+    endpointFlag          endpointFlags
+)
+
+func init() {
+    flag.Var(&endpointFlag, "endpoint", "Add or replace an endpoint as name=url (repeatable)")
+}
+
+// Environment variables overriding the config file.
+const (
+    envInterval          = "RPC_CHECKER_INTERVAL"
+    envMethod            = "RPC_CHECKER_METHOD"
+    envPrometheusAddress = "RPC_CHECKER_PROMETHEUS_ADDRESS"
+)
+
+// endpointFlags collects repeated -endpoint name=url flags.
+type endpointFlags []Endpoint
+
+func (e *endpointFlags) String() string {
+    if e == nil {
+        return ""
+    }
+    names := make([]string, len(*e))
+    for i, endpoint := range *e {
+        names[i] = endpoint.Name
+    }
+    return strings.Join(names, ",")
+}
+
+func (e *endpointFlags) Set(value string) error {
+    name, url, ok := strings.Cut(value, "=")
+    if !ok || name == "" || url == "" {
+        return fmt.Errorf("expected name=url, got %q", value)
+    }
+    *e = append(*e, Endpoint{Name: name, URL: url})
+    return nil
+}
+
+// applyOverrides applies environment variables and then command-line flags
+// on top of the values loaded from the config file.
+func applyOverrides(config *Config) error {
+    interval := os.Getenv(envInterval)
+    method := os.Getenv(envMethod)
+    address := os.Getenv(envPrometheusAddress)
+    if *intervalFlag != "" {
+        interval = *intervalFlag
+    }
+    if *methodFlag != "" {
+        method = *methodFlag
+    }
+    if *prometheusAddressFlag != "" {
+        address = *prometheusAddressFlag
+    }
+
+    if interval != "" {
+        d, err := parseInterval(interval)
+        if err != nil {
+            return fmt.Errorf("invalid interval override %q: %v", interval, err)
+        }
+        config.Interval = Interval(d)
+    }
+    if method != "" {
+        config.Method = method
+    }
+    if address != "" {
+        config.Prometheus.Address = address
+    }
+
+    // An endpoint flag replaces a file endpoint of the same name
+    for _, override := range endpointFlag {
+        replaced := false
+        for i, endpoint := range config.Endpoints {
+            if endpoint.Name == override.Name {
+                config.Endpoints[i] = override
+                replaced = true
+                break
+            }
+        }
+        if !replaced {
+            config.Endpoints = append(config.Endpoints, override)
+        }
+    }
+    return nil
+}
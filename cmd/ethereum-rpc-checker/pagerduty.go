@@ -0,0 +1,105 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "time"
+)
+
+// defaultPagerDutyEventsURL is the Events API v2 endpoint when
+// pagerduty.events_url isn't set, e.g. for the EU service region.
+const defaultPagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// pagerDutyMaxSummaryLength is the longest summary, in characters, the
+// Events API v2 accepts. The full error is kept in the custom details.
+const pagerDutyMaxSummaryLength = 1024
+
+// pagerDutySeverities are the severities the Events API v2 accepts.
+var pagerDutySeverities = map[string]bool{"critical": true, "error": true, "warning": true, "info": true}
+
+// PagerDutyConfig describes the PagerDuty service incidents are opened on.
+type PagerDutyConfig struct {
+    RoutingKey string `yaml:"routing_key"`
+    // Severity of the triggered incidents, critical by default
+    Severity  string `yaml:"severity"`
+    EventsURL string `yaml:"events_url"`
+}
+
+// validatePagerDuty checks the PagerDuty settings.
+func validatePagerDuty(config PagerDutyConfig) []error {
+    var problems []error
+    if config.RoutingKey == "" {
+        if config.Severity != "" || config.EventsURL != "" {
+            problems = append(problems, fmt.Errorf("pagerduty settings require a routing_key"))
+        }
+        return problems
+    }
+    if config.Severity != "" && !pagerDutySeverities[config.Severity] {
+        problems = append(problems, fmt.Errorf("unknown pagerduty severity %q, expected critical, error, warning or info", config.Severity))
+    }
+    if err := validateWebhookURL("pagerduty events_url", config.EventsURL); err != nil {
+        problems = append(problems, err)
+    }
+    return problems
+}
+
+// pagerDutyNotifier triggers an incident when an endpoint becomes unhealthy
+// and resolves it when the endpoint recovers. Both events carry the same
+// dedup key, derived from the endpoint name, so PagerDuty matches them.
+type pagerDutyNotifier struct {
+    config PagerDutyConfig
+}
+
+type pagerDutyEvent struct {
+    RoutingKey  string            `json:"routing_key"`
+    EventAction string            `json:"event_action"`
+    DedupKey    string            `json:"dedup_key"`
+    Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+    Summary       string            `json:"summary"`
+    Source        string            `json:"source"`
+    Severity      string            `json:"severity"`
+    Timestamp     string            `json:"timestamp"`
+    Component     string            `json:"component"`
+    CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+func (pagerDutyNotifier) Name() string { return "PagerDuty" }
+
+func (n pagerDutyNotifier) Notify(ctx context.Context, change StatusChange) error {
+    event := pagerDutyEvent{
+        RoutingKey:  n.config.RoutingKey,
+        EventAction: "resolve",
+        DedupKey:    "ethereum-rpc-checker/" + change.Endpoint,
+    }
+    if !change.Healthy {
+        severity := n.config.Severity
+        if severity == "" {
+            severity = "critical"
+        }
+        source, err := os.Hostname()
+        if err != nil {
+            source = "ethereum-rpc-checker"
+        }
+        event.EventAction = "trigger"
+        event.Payload = &pagerDutyPayload{
+            Summary:   truncate(fmt.Sprintf("Endpoint %s is unhealthy: %v", change.Endpoint, change.Err), pagerDutyMaxSummaryLength),
+            Source:    source,
+            Severity:  severity,
+            Timestamp: change.Time.Format(time.RFC3339),
+            Component: change.Endpoint,
+        }
+        if change.Err != nil {
+            event.Payload.CustomDetails = map[string]string{"error": change.Err.Error()}
+        }
+    }
+
+    eventsURL := n.config.EventsURL
+    if eventsURL == "" {
+        eventsURL = defaultPagerDutyEventsURL
+    }
+    return postWebhook(ctx, eventsURL, event)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "net/url"
+    "regexp"
+    "strings"
+
+    "gopkg.in/yaml.v3"
+)
+
+// redacted replaces secrets in the output of -print-config.
+const redacted = "********"
+
+// apiKeySegment matches URL path segments that look like an API key, as in
+// https://mainnet.infura.io/v3/<key>.
+var apiKeySegment = regexp.MustCompile(`^[A-Za-z0-9_-]{20,}$`)
+
+// printConfig writes the effective configuration as YAML: overrides applied,
+// every endpoint resolved against the global settings, and secrets redacted
+// so the output can be pasted into an issue. It backs the -print-config flag.
+func printConfig(w io.Writer, config Config) error {
+    enc := yaml.NewEncoder(w)
+    enc.SetIndent(2)
+    if err := enc.Encode(redactConfig(config)); err != nil {
+        return fmt.Errorf("error marshalling config: %v", err)
+    }
+    return enc.Close()
+}
+
+// redactConfig returns config with the defaults and every endpoint resolved,
+// and every secret redacted: URL credentials, query values and API keys in
+// paths, header values, the basic auth password, webhook URLs and the
+// PagerDuty routing key.
+func redactConfig(config Config) Config {
+    if config.MaxConcurrentChecks <= 0 {
+        config.MaxConcurrentChecks = defaultMaxConcurrentChecks
+    }
+    if config.Prometheus.Path == "" {
+        config.Prometheus.Path = defaultMetricsPath
+    }
+
+    endpoints := make([]Endpoint, 0, len(config.Endpoints))
+    for _, endpoint := range config.Endpoints {
+        endpoint = resolveEndpoint(config, endpoint).Endpoint
+        endpoint.URL = redactURL(endpoint.URL)
+        fallbacks := make([]string, 0, len(endpoint.FallbackURLs))
+        for _, fallback := range endpoint.FallbackURLs {
+            fallbacks = append(fallbacks, redactURL(fallback))
+        }
+        endpoint.FallbackURLs = fallbacks
+        if endpoint.Proxy != "" {
+            endpoint.Proxy = redactURL(endpoint.Proxy)
+        }
+        if len(endpoint.Headers) > 0 {
+            headers := make(Headers, len(endpoint.Headers))
+            for name := range endpoint.Headers {
+                headers[name] = redacted
+            }
+            endpoint.Headers = headers
+        }
+        endpoints = append(endpoints, endpoint)
+    }
+    config.Endpoints = endpoints
+
+    // The global settings show the defaults endpoints without their own value get
+    global := resolveEndpoint(config, Endpoint{})
+    config.DialTimeout, config.CallTimeout = global.DialTimeout, global.CallTimeout
+    config.Redirects, config.UserAgent = global.Redirects, global.UserAgent
+    config.UnsupportedMethods = global.UnsupportedMethods
+    config.RetryBackoff = global.RetryBackoff
+    config.FailureThreshold, config.SuccessThreshold = global.FailureThreshold, global.SuccessThreshold
+    config.Transport = global.Transport
+
+    if config.Prometheus.BasicAuth.Password != "" {
+        config.Prometheus.BasicAuth.Password = redacted
+    }
+    // Webhook URLs are themselves the secret
+    if config.Slack.WebhookURL != "" {
+        config.Slack.WebhookURL = redacted
+    }
+    if config.Discord.WebhookURL != "" {
+        config.Discord.WebhookURL = redacted
+    }
+    if config.PagerDuty.RoutingKey != "" {
+        config.PagerDuty.RoutingKey = redacted
+    }
+    if config.PagerDuty.EventsURL != "" {
+        config.PagerDuty.EventsURL = redactURL(config.PagerDuty.EventsURL)
+    }
+    if config.Pushgateway.URL != "" {
+        config.Pushgateway.URL = redactURL(config.Pushgateway.URL)
+    }
+    if config.Tracing.Endpoint != "" {
+        config.Tracing.Endpoint = redactURL(config.Tracing.Endpoint)
+    }
+    return config
+}
+
+// redactURL masks more than maskSensitiveInfo, which keeps the logs
+// readable: every query value and every path segment that looks like an API
+// key are redacted along with the credentials. IPC paths are kept as is.
+func redactURL(rawURL string) string {
+    if _, ok := ipcPath(rawURL); ok {
+        return rawURL
+    }
+    parsedURL, err := url.Parse(rawURL)
+    if err != nil {
+        return redacted
+    }
+    if parsedURL.User != nil {
+        parsedURL.User = url.UserPassword(redacted, redacted)
+    }
+    query := parsedURL.Query()
+    for key := range query {
+        query.Set(key, redacted)
+    }
+    parsedURL.RawQuery = query.Encode()
+    segments := strings.Split(parsedURL.Path, "/")
+    for i, segment := range segments {
+        if apiKeySegment.MatchString(segment) {
+            segments[i] = redacted
+        }
+    }
+    parsedURL.Path = strings.Join(segments, "/")
+    parsedURL.RawPath = ""
+    // The asterisks are valid in every part of a URL, so keep them readable
+    return strings.ReplaceAll(parsedURL.String(), "%2A", "*")
+}
+
+// embeddedURL matches the URLs quoted in error messages: go-ethereum and
+// net/http errors carry the full request URL, API keys included.
+var embeddedURL = regexp.MustCompile(`[A-Za-z][A-Za-z0-9+.-]*://[^\s"'<>]+`)
+
+// redactError returns the message of err with every URL in it redacted like
+// redactURL, so it can be shown outside the checker's own logs.
+func redactError(err error) string {
+    return embeddedURL.ReplaceAllStringFunc(err.Error(), redactURL)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "io"
+    "time"
+)
+
+var (
+    probeURL    = flag.String("url", "", "Check this endpoint URL once with -check-method and exit, without a config file")
+    checkMethod = flag.String("check-method", "eth_blockNumber", "RPC method called by -url")
+)
+
+// probeEndpoint returns the endpoint checked by -url: rawURL called with
+// method and the default settings. Methods the checker doesn't know only
+// need to succeed.
+func probeEndpoint(rawURL, method string) (Endpoint, error) {
+    endpoint := Endpoint{
+        Name:       maskSensitiveInfo(rawURL),
+        URL:        rawURL,
+        Method:     method,
+        ResultType: resultTypeFor(method, "", resultTypeNone),
+    }
+    if _, ok := ipcPath(rawURL); ok {
+        endpoint.Name = rawURL
+    }
+    if err := validateEndpoint(&endpoint, 1); err != nil {
+        return Endpoint{}, err
+    }
+    return endpoint, nil
+}
+
+// runProbe checks endpoint once, prints the outcome to w and reports whether
+// it was healthy. It backs the -url flag for ad hoc troubleshooting, so it
+// needs neither a config file nor the metrics server; the metrics it updates
+// are never served.
+func runProbe(ctx context.Context, w io.Writer, endpoint Endpoint, debug bool) bool {
+    method := endpoint.Method
+    metrics = newMetrics(Config{})
+    result := checkBlockchainRPC(ctx, endpoint, Config{Debug: debug})
+    rpcClients.closeAll()
+
+    latency := result.Latency.Round(time.Millisecond)
+    if !result.Healthy {
+        if result.ErrorCategory != "" {
+            fmt.Fprintf(w, "❌ %s: %s failed after %s: %v (%s)\n", endpoint.Name, method, latency, result.Err, result.ErrorCategory)
+        } else {
+            fmt.Fprintf(w, "❌ %s: %s failed after %s: %v\n", endpoint.Name, method, latency, result.Err)
+        }
+        return false
+    }
+    switch {
+    case result.HasBlockNumber:
+        fmt.Fprintf(w, "✅ %s: %s returned block %d in %s\n", endpoint.Name, method, result.BlockNumber, latency)
+    case result.Data != nil:
+        fmt.Fprintf(w, "✅ %s: %s returned %s in %s\n", endpoint.Name, method, *result.Data, latency)
+    case endpoint.ResultType != resultTypeNone && result.Summary != "":
+        fmt.Fprintf(w, "✅ %s: %s returned %s in %s\n", endpoint.Name, method, result.Summary, latency)
+    default:
+        // Methods the checker doesn't decode show the result as is
+        fmt.Fprintf(w, "✅ %s: %s returned %s in %s\n", endpoint.Name, method, result.Result, latency)
+    }
+    return true
+}
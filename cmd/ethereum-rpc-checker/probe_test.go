@@ -0,0 +1,93 @@
+package main
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+// newTestRPCServer answers JSON-RPC calls with the result of their method
+// in results, and every other method with "method not found".
+func newTestRPCServer(t *testing.T, results map[string]string) *httptest.Server {
+    type request struct {
+        ID     json.RawMessage `json:"id"`
+        Method string          `json:"method"`
+    }
+    answer := func(req request) map[string]any {
+        response := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+        if result, ok := results[req.Method]; ok {
+            response["result"] = json.RawMessage(result)
+        } else {
+            response["error"] = map[string]any{"code": methodNotFoundCode, "message": "Method not found"}
+        }
+        return response
+    }
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        var body json.RawMessage
+        if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+            var batch []request
+            json.Unmarshal(body, &batch)
+            responses := make([]map[string]any, 0, len(batch))
+            for _, req := range batch {
+                responses = append(responses, answer(req))
+            }
+            json.NewEncoder(w).Encode(responses)
+            return
+        }
+        var req request
+        json.Unmarshal(body, &req)
+        json.NewEncoder(w).Encode(answer(req))
+    }))
+    t.Cleanup(server.Close)
+    return server
+}
+
+func TestRunProbe(t *testing.T) {
+    server := newTestRPCServer(t, map[string]string{
+        "eth_blockNumber":    `"0x10"`,
+        "eth_chainId":        `"0x1"`,
+        "net_version":        `"1"`,
+        "net_peerCount":      `"0x19"`,
+        "web3_clientVersion": `"Geth/v1.14.11-stable/linux-amd64/go1.22.4"`,
+    })
+
+    tests := []struct {
+        method      string
+        wantHealthy bool
+        want        string
+    }{
+        {method: "eth_blockNumber", wantHealthy: true, want: "eth_blockNumber returned block 16 in"},
+        {method: "eth_chainId", wantHealthy: true, want: "eth_chainId returned chain 1 in"},
+        {method: "net_version", wantHealthy: true, want: "net_version returned network 1 in"},
+        {method: "net_peerCount", wantHealthy: true, want: "net_peerCount returned 25 peers in"},
+        // Methods without a handler show the raw result
+        {method: "web3_clientVersion", wantHealthy: true, want: `web3_clientVersion returned "Geth/v1.14.11-stable/linux-amd64/go1.22.4" in`},
+        {method: "eth_unknown", want: "eth_unknown failed after"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.method, func(t *testing.T) {
+            endpoint, err := probeEndpoint(server.URL, tt.method)
+            if err != nil {
+                t.Fatalf("probeEndpoint(%s) returned error: %v", tt.method, err)
+            }
+            var out bytes.Buffer
+            healthy := runProbe(context.Background(), &out, endpoint, false)
+            if healthy != tt.wantHealthy {
+                t.Errorf("runProbe(%s) = %v, want %v: %s", tt.method, healthy, tt.wantHealthy, out.String())
+            }
+            if !strings.Contains(out.String(), tt.want) {
+                t.Errorf("runProbe(%s) printed %q, want it to contain %q", tt.method, out.String(), tt.want)
+            }
+        })
+    }
+}
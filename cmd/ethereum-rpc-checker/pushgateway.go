@@ -0,0 +1,106 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "net/url"
+    "os"
+    "sort"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus/push"
+)
+
+const (
+    // defaultPushJob is the job label of pushed metrics when pushgateway.job isn't set.
+    defaultPushJob = "ethereum-rpc-checker"
+    // pushTimeout bounds a single push so a slow gateway can't pile them up.
+    pushTimeout = 10 * time.Second
+)
+
+// PushgatewayConfig describes where metrics are pushed for short-lived runs
+// that exit before Prometheus would scrape them.
+type PushgatewayConfig struct {
+    URL string `yaml:"url"`
+    Job string `yaml:"job"`
+    // Grouping labels identify this checker's metrics on the gateway so
+    // several checkers pushing the same job don't replace each other.
+    // Defaults to the hostname as instance.
+    Grouping map[string]string `yaml:"grouping"`
+}
+
+// validatePushgateway checks the pushgateway settings.
+func validatePushgateway(config PushgatewayConfig) []error {
+    var problems []error
+    if config.URL == "" {
+        return nil
+    }
+    parsedURL, err := url.Parse(config.URL)
+    if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+        problems = append(problems, fmt.Errorf("invalid pushgateway url %q", config.URL))
+    }
+    for name := range config.Grouping {
+        if !metricNamespacePattern.MatchString(name) {
+            problems = append(problems, fmt.Errorf("invalid pushgateway grouping label %q", name))
+        }
+    }
+    return problems
+}
+
+// newPusher builds a pusher for the metrics registry from the config.
+func newPusher(config PushgatewayConfig) *push.Pusher {
+    job := config.Job
+    if job == "" {
+        job = defaultPushJob
+    }
+    pusher := push.New(config.URL, job).Gatherer(metrics.registry)
+
+    grouping := config.Grouping
+    if len(grouping) == 0 {
+        hostname, err := os.Hostname()
+        if err != nil {
+            hostname = "unknown"
+        }
+        grouping = map[string]string{"instance": hostname}
+    }
+    names := make([]string, 0, len(grouping))
+    for name := range grouping {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        pusher = pusher.Grouping(name, grouping[name])
+    }
+    return pusher
+}
+
+// pushMetrics pushes the current metrics to the gateway, replacing the ones
+// previously pushed with the same grouping labels. It does nothing when no
+// gateway is configured.
+func pushMetrics(ctx context.Context, config Config) {
+    if config.Pushgateway.URL == "" {
+        return
+    }
+    ctx, cancel := context.WithTimeout(ctx, pushTimeout)
+    defer cancel()
+    if err := newPusher(config.Pushgateway).PushContext(ctx); err != nil {
+        slog.Error(fmt.Sprintf("❌ Failed to push metrics to %s: %v", maskSensitiveInfo(config.Pushgateway.URL), err), "error", err)
+        return
+    }
+    slog.Debug(fmt.Sprintf("📤 Pushed metrics to %s", maskSensitiveInfo(config.Pushgateway.URL)))
+}
+
+// schedulePushes pushes the metrics once per interval until ctx is cancelled.
+func schedulePushes(ctx context.Context, config Config) {
+    ticker := time.NewTicker(config.Interval.Duration())
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            pushMetrics(ctx, config)
+        }
+    }
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/ethereum/go-ethereum/rpc"
+    "golang.org/x/time/rate"
+)
+
+// maxRetryAfter caps the Retry-After delay honored for a rate-limited
+// endpoint, so a misbehaving provider can't pause its checks for hours.
+const maxRetryAfter = 5 * time.Minute
+
+// rateLimitTransport records the Retry-After delay of 429 responses for an
+// endpoint. The RPC client only surfaces the status code, not the headers.
+type rateLimitTransport struct {
+    base     http.RoundTripper
+    endpoint string
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    resp, err := t.base.RoundTrip(req)
+    if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+        if delay := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); delay > 0 {
+            endpointStates.rateLimit(t.endpoint, delay)
+        }
+    }
+    return resp, err
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as
+// an HTTP date, bounded to maxRetryAfter. It returns 0 when the header is
+// missing or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+    value = strings.TrimSpace(value)
+    if value == "" {
+        return 0
+    }
+    var delay time.Duration
+    if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+        if seconds > int64(maxRetryAfter/time.Second) {
+            return maxRetryAfter
+        }
+        delay = time.Duration(seconds) * time.Second
+    } else if date, err := http.ParseTime(value); err == nil {
+        delay = date.Sub(now)
+    }
+    if delay < 0 {
+        return 0
+    }
+    return min(delay, maxRetryAfter)
+}
+
+// rateLimitedError marks a 429 response and carries the delay the endpoint
+// asked for, 0 if it didn't send a usable Retry-After.
+type rateLimitedError struct {
+    err        error
+    retryAfter time.Duration
+}
+
+func (e *rateLimitedError) Error() string { return e.err.Error() }
+func (e *rateLimitedError) Unwrap() error { return e.err }
+
+// isRateLimited reports whether err is an HTTP 429 response.
+func isRateLimited(err error) bool {
+    var httpErr rpc.HTTPError
+    return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests
+}
+
+// errRateBudget is returned when an endpoint's rate_limit leaves no room for
+// a request before the call timeout. It counts as a timeout.
+var errRateBudget = errors.New("no rate_limit budget left before the call timeout")
+
+// requestLimiterStore enforces the rate_limit of every endpoint. Each request
+// draws from the endpoint's budget, whether it is a retry, a method batch, a
+// balance batch or a fallback URL, so the limit holds however the checks run.
+type requestLimiterStore struct {
+    mu       sync.Mutex
+    limiters map[string]*rate.Limiter
+}
+
+var requestLimiters = &requestLimiterStore{limiters: make(map[string]*rate.Limiter)}
+
+// wait blocks until endpoint may send a request, or fails when ctx would end
+// first. It returns right away for endpoints without a rate_limit.
+func (s *requestLimiterStore) wait(ctx context.Context, endpoint Endpoint) error {
+    if endpoint.RateLimit <= 0 {
+        return nil
+    }
+    if err := s.limiter(endpoint).Wait(ctx); err != nil {
+        return fmt.Errorf("%w (%g requests per second): %w", errRateBudget, endpoint.RateLimit, context.DeadlineExceeded)
+    }
+    return nil
+}
+
+// limiter returns the limiter of endpoint, updated to its current rate_limit.
+// A burst of 1 keeps requests evenly spaced.
+func (s *requestLimiterStore) limiter(endpoint Endpoint) *rate.Limiter {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    limit := rate.Limit(endpoint.RateLimit)
+    limiter, ok := s.limiters[endpoint.Name]
+    if !ok {
+        limiter = rate.NewLimiter(limit, 1)
+        s.limiters[endpoint.Name] = limiter
+    } else if limiter.Limit() != limit {
+        limiter.SetLimit(limit)
+    }
+    return limiter
+}
+
+// remove forgets the limiter of an endpoint that is no longer configured.
+func (s *requestLimiterStore) remove(name string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    delete(s.limiters, name)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+    "fmt"
+    "log/slog"
+    "reflect"
+)
+
+// reloadConfig re-reads the config file. On error the caller keeps running
+// with the previous config.
+func reloadConfig(filename string) (Config, error) {
+    if filename == stdinConfig {
+        return Config{}, fmt.Errorf("the config was read from standard input, restart to change it")
+    }
+    config, err := loadConfigFile(filename)
+    if err != nil {
+        return Config{}, err
+    }
+    config.Debug = *debugMode
+    return config, nil
+}
+
+// applyReload logs what changed between two configs, cleans up state
+// belonging to endpoints that were removed or changed, and rebuilds the
+// metrics when their settings or the extractor metrics changed. The checks
+// must be stopped.
+func applyReload(oldConfig, newConfig Config) {
+    if oldConfig.Interval != newConfig.Interval {
+        slog.Info(fmt.Sprintf("🔄 Interval changed from %s to %s", oldConfig.Interval.Duration(), newConfig.Interval.Duration()))
+    }
+    if oldConfig.Method != newConfig.Method {
+        slog.Info(fmt.Sprintf("🔄 Method changed from %s to %s", oldConfig.Method, newConfig.Method))
+    }
+    if oldConfig.DialTimeout != newConfig.DialTimeout || oldConfig.CallTimeout != newConfig.CallTimeout {
+        slog.Info(fmt.Sprintf("🔄 Timeouts changed to dial %s, call %s", newConfig.DialTimeout.Duration(), newConfig.CallTimeout.Duration()))
+        // Clients carry the timeouts they were dialed with
+        rpcClients.closeAll()
+    }
+    if oldConfig.InsecureSkipVerify != newConfig.InsecureSkipVerify {
+        slog.Info(fmt.Sprintf("🔄 insecure_skip_verify changed to %v", newConfig.InsecureSkipVerify))
+        rpcClients.closeAll()
+    }
+    if oldConfig.Transport != newConfig.Transport {
+        slog.Info("🔄 Transport settings changed")
+        rpcClients.closeAll()
+    }
+    if oldConfig.UserAgent != newConfig.UserAgent {
+        slog.Info(fmt.Sprintf("🔄 User-Agent changed to %s", resolveEndpoint(newConfig, Endpoint{}).UserAgent))
+        rpcClients.closeAll()
+    }
+    if oldConfig.Redirects != newConfig.Redirects {
+        slog.Info(fmt.Sprintf("🔄 Redirects policy changed to %s", resolveEndpoint(newConfig, Endpoint{}).Redirects))
+        rpcClients.closeAll()
+    }
+    if oldConfig.StateFile != newConfig.StateFile {
+        slog.Info(fmt.Sprintf("🔄 State file changed to %s", newConfig.StateFile))
+    }
+    if oldConfig.Prometheus != newConfig.Prometheus {
+        slog.Warn("⚠️ Prometheus settings changes require a restart")
+    }
+    if oldConfig.Tracing != newConfig.Tracing {
+        slog.Warn("⚠️ Tracing settings changes require a restart")
+    }
+    // The metrics are registered with their settings, so they are rebuilt
+    // once the endpoints are cleaned up
+    rebuild := false
+    if !reflect.DeepEqual(oldConfig.Metrics, newConfig.Metrics) {
+        slog.Info("🔄 Metrics settings changed")
+        rebuild = true
+    }
+
+    newEndpoints := make(map[string]Endpoint, len(newConfig.Endpoints))
+    for _, endpoint := range newConfig.Endpoints {
+        newEndpoints[endpoint.Name] = endpoint
+    }
+    oldEndpoints := make(map[string]Endpoint, len(oldConfig.Endpoints))
+    for _, endpoint := range oldConfig.Endpoints {
+        oldEndpoints[endpoint.Name] = endpoint
+    }
+
+    for name, endpoint := range oldEndpoints {
+        updated, ok := newEndpoints[name]
+        switch {
+        case !ok:
+            slog.Info(fmt.Sprintf("➖ Endpoint removed: %s", name), "endpoint", name)
+            rpcClients.discard(name)
+            endpointStates.remove(name)
+            requestLimiters.remove(name)
+            endpointStatuses.remove(name)
+            groups.remove(name)
+            metrics.resetEndpoint(name)
+        case !reflect.DeepEqual(endpoint, updated):
+            slog.Info(fmt.Sprintf("✏️ Endpoint changed: %s", name), "endpoint", name)
+            // Redial so new URLs and headers take effect
+            rpcClients.discard(name)
+            metrics.resetConfiguredSeries(name)
+            if endpoint.Group != updated.Group {
+                groups.remove(name)
+                metrics.deleteEndpoint(metrics.blockDrift.MetricVec, "block_drift", name)
+            }
+        }
+    }
+    for name, endpoint := range newEndpoints {
+        if _, ok := oldEndpoints[name]; !ok {
+            slog.Info(fmt.Sprintf("➕ Endpoint added: %s", name), "endpoint", name)
+        }
+        for _, extractor := range endpoint.Extract {
+            if _, ok := metrics.extracted[extractor.Metric]; !ok {
+                slog.Info(fmt.Sprintf("🔄 New extractor metric %s on %s", extractor.Metric, name),
+                    "endpoint", name, "metric", extractor.Metric)
+                rebuild = true
+            }
+        }
+    }
+    if rebuild {
+        rebuildMetrics(newConfig)
+    }
+}
+
+// rebuildMetrics replaces the metrics with ones built from config, serving
+// them from the next scrape on. As after a restart the counters start from
+// zero, and the checks that follow the reload set every series again. The
+// last success timestamps are restored since only a success sets them, and
+// the clients are redialed so their certificate expiry is recorded again.
+func rebuildMetrics(config Config) {
+    slog.Info("📊 Rebuilding the metrics, counters restart from zero")
+    useMetrics(newMetrics(config))
+    for _, endpoint := range config.Endpoints {
+        if lastSuccess, _ := endpointStatuses.saved(endpoint.Name); lastSuccess != nil {
+            metrics.set(metrics.lastSuccess, "rpc_last_success_timestamp_seconds", float64(lastSuccess.UnixNano())/1e9, endpoint.Name)
+        }
+    }
+    rpcClients.closeAll()
+}
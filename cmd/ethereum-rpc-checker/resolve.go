@@ -0,0 +1,130 @@
+package main
+
+import "time"
+
+// defaultTimeout is the dial and call timeout when none is configured.
+const defaultTimeout = 30 * time.Second
+
+// ResolvedEndpoint is an endpoint with the global settings and defaults it
+// inherits applied, so the checker never has to merge them itself.
+type ResolvedEndpoint struct {
+    // Endpoint holds the effective value of every setting that has a global
+    // form: method, interval, timeouts, TLS verification, redirects,
+    // transport, user agent, retries, thresholds and unsupported_methods
+    Endpoint
+    Retry retryPolicy
+}
+
+// resolveEndpoint applies the global config and the defaults to endpoint.
+// Every setting follows the same rule: the endpoint's own value, else the
+// global one, else the default. An endpoint value is unset when it is zero,
+// except insecure_skip_verify and retries, whose nil inherits so false and 0
+// can override the global value. The thresholds are at least 1, and the
+// transport settings are merged one by one.
+func resolveEndpoint(config Config, endpoint Endpoint) ResolvedEndpoint {
+    if endpoint.Method == "" {
+        endpoint.Method = config.Method
+    }
+    if endpoint.Interval <= 0 {
+        endpoint.Interval = config.Interval
+    }
+
+    if endpoint.DialTimeout <= 0 {
+        endpoint.DialTimeout = config.DialTimeout
+    }
+    if endpoint.DialTimeout <= 0 {
+        endpoint.DialTimeout = Duration(defaultTimeout)
+    }
+    if endpoint.CallTimeout <= 0 {
+        endpoint.CallTimeout = config.CallTimeout
+    }
+    if endpoint.CallTimeout <= 0 {
+        endpoint.CallTimeout = Duration(defaultTimeout)
+    }
+
+    if endpoint.InsecureSkipVerify == nil {
+        insecure := config.InsecureSkipVerify
+        endpoint.InsecureSkipVerify = &insecure
+    }
+    endpoint.Redirects = firstNonEmpty(endpoint.Redirects, config.Redirects, redirectsSameHost)
+    endpoint.UserAgent = firstNonEmpty(endpoint.UserAgent, config.UserAgent, "ethereum-rpc-checker/"+version)
+    endpoint.UnsupportedMethods = firstNonEmpty(endpoint.UnsupportedMethods, config.UnsupportedMethods, unsupportedMethodsSkip)
+    endpoint.Transport = resolveTransport(endpoint.Transport, config.Transport)
+
+    if endpoint.Retries == nil {
+        retries := config.Retries
+        endpoint.Retries = &retries
+    }
+    if endpoint.RetryBackoff <= 0 {
+        endpoint.RetryBackoff = config.RetryBackoff
+    }
+    if endpoint.RetryBackoff <= 0 {
+        endpoint.RetryBackoff = Duration(defaultRetryBackoff)
+    }
+
+    // 0 disables stall detection, so there is no default
+    if endpoint.StallThreshold <= 0 {
+        endpoint.StallThreshold = config.StallThreshold
+    }
+    if endpoint.FailureThreshold <= 0 {
+        endpoint.FailureThreshold = config.FailureThreshold
+    }
+    if endpoint.SuccessThreshold <= 0 {
+        endpoint.SuccessThreshold = config.SuccessThreshold
+    }
+    endpoint.FailureThreshold = max(endpoint.FailureThreshold, 1)
+    endpoint.SuccessThreshold = max(endpoint.SuccessThreshold, 1)
+
+    return ResolvedEndpoint{
+        Endpoint: endpoint,
+        Retry:    retryPolicy{retries: *endpoint.Retries, backoff: endpoint.RetryBackoff.Duration()},
+    }
+}
+
+// resolveTransport returns the endpoint's transport settings, falling back
+// to the global ones and then to the defaults, setting by setting.
+func resolveTransport(endpoint, global TransportConfig) TransportConfig {
+    merged := global
+    if endpoint.MaxIdleConnsPerHost > 0 {
+        merged.MaxIdleConnsPerHost = endpoint.MaxIdleConnsPerHost
+    }
+    if endpoint.IdleConnTimeout > 0 {
+        merged.IdleConnTimeout = endpoint.IdleConnTimeout
+    }
+    if endpoint.TLSHandshakeTimeout > 0 {
+        merged.TLSHandshakeTimeout = endpoint.TLSHandshakeTimeout
+    }
+    if endpoint.KeepAlive > 0 {
+        merged.KeepAlive = endpoint.KeepAlive
+    }
+    if endpoint.MaxResponseSize > 0 {
+        merged.MaxResponseSize = endpoint.MaxResponseSize
+    }
+
+    if merged.MaxIdleConnsPerHost <= 0 {
+        merged.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+    }
+    if merged.IdleConnTimeout <= 0 {
+        merged.IdleConnTimeout = Duration(defaultIdleConnTimeout)
+    }
+    if merged.TLSHandshakeTimeout <= 0 {
+        merged.TLSHandshakeTimeout = Duration(defaultTLSHandshakeTimeout)
+    }
+    if merged.KeepAlive <= 0 {
+        merged.KeepAlive = Duration(defaultKeepAlive)
+    }
+    if merged.MaxResponseSize <= 0 {
+        merged.MaxResponseSize = defaultMaxResponseSize
+    }
+    return merged
+}
+
+// firstNonEmpty returns the first of values that isn't empty.
+func firstNonEmpty(values ...string) string {
+    for _, v := range values {
+        if v != "" {
+            return v
+        }
+    }
+    return ""
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+    "reflect"
+    "testing"
+    "time"
+)
+
+func ptr[T any](v T) *T {
+    return &v
+}
+
+func TestResolveEndpoint(t *testing.T) {
+    global := Config{
+        Method:             "eth_chainId",
+        Interval:           Interval(time.Minute),
+        Retries:            2,
+        RetryBackoff:       Duration(time.Second),
+        StallThreshold:     3,
+        FailureThreshold:   2,
+        SuccessThreshold:   2,
+        DialTimeout:        Duration(5 * time.Second),
+        CallTimeout:        Duration(10 * time.Second),
+        InsecureSkipVerify: true,
+        Redirects:          redirectsFollow,
+        UnsupportedMethods: unsupportedMethodsUnhealthy,
+        UserAgent:          "global-agent",
+        Transport:          TransportConfig{MaxIdleConnsPerHost: 10, KeepAlive: Duration(15 * time.Second)},
+    }
+    // overrides sets every setting, and false and 0 where the zero value
+    // must win over the global one
+    overrides := Endpoint{
+        Name:               "node",
+        Method:             "eth_blockNumber",
+        Interval:           Interval(30 * time.Second),
+        InsecureSkipVerify: ptr(false),
+        Redirects:          redirectsReject,
+        UnsupportedMethods: unsupportedMethodsSkip,
+        UserAgent:          "endpoint-agent",
+        Retries:            ptr(0),
+        RetryBackoff:       Duration(250 * time.Millisecond),
+        StallThreshold:     5,
+        FailureThreshold:   4,
+        SuccessThreshold:   3,
+        DialTimeout:        Duration(time.Second),
+        CallTimeout:        Duration(2 * time.Second),
+        Transport:          TransportConfig{IdleConnTimeout: Duration(30 * time.Second), KeepAlive: Duration(5 * time.Second)},
+    }
+
+    defaultTransport := TransportConfig{
+        MaxIdleConnsPerHost: defaultMaxIdleConnsPerHost,
+        IdleConnTimeout:     Duration(defaultIdleConnTimeout),
+        TLSHandshakeTimeout: Duration(defaultTLSHandshakeTimeout),
+        KeepAlive:           Duration(defaultKeepAlive),
+        MaxResponseSize:     defaultMaxResponseSize,
+    }
+    globalTransport := defaultTransport
+    globalTransport.MaxIdleConnsPerHost = 10
+    globalTransport.KeepAlive = Duration(15 * time.Second)
+    endpointTransport := defaultTransport
+    endpointTransport.IdleConnTimeout = Duration(30 * time.Second)
+    endpointTransport.KeepAlive = Duration(5 * time.Second)
+    // Transport settings are merged one by one
+    mergedTransport := endpointTransport
+    mergedTransport.MaxIdleConnsPerHost = 10
+
+    tests := []struct {
+        name     string
+        config   Config
+        endpoint Endpoint
+        want     ResolvedEndpoint
+    }{
+        {
+            name:     "defaults",
+            endpoint: Endpoint{Name: "node"},
+            want: ResolvedEndpoint{
+                Endpoint: Endpoint{
+                    Name:               "node",
+                    InsecureSkipVerify: ptr(false),
+                    Redirects:          redirectsSameHost,
+                    UnsupportedMethods: unsupportedMethodsSkip,
+                    UserAgent:          "ethereum-rpc-checker/" + version,
+                    Retries:            ptr(0),
+                    RetryBackoff:       Duration(defaultRetryBackoff),
+                    FailureThreshold:   1,
+                    SuccessThreshold:   1,
+                    DialTimeout:        Duration(defaultTimeout),
+                    CallTimeout:        Duration(defaultTimeout),
+                    Transport:          defaultTransport,
+                },
+                Retry: retryPolicy{retries: 0, backoff: defaultRetryBackoff},
+            },
+        },
+        {
+            name:     "global only",
+            config:   global,
+            endpoint: Endpoint{Name: "node"},
+            want: ResolvedEndpoint{
+                Endpoint: Endpoint{
+                    Name:               "node",
+                    Method:             "eth_chainId",
+                    Interval:           Interval(time.Minute),
+                    InsecureSkipVerify: ptr(true),
+                    Redirects:          redirectsFollow,
+                    UnsupportedMethods: unsupportedMethodsUnhealthy,
+                    UserAgent:          "global-agent",
+                    Retries:            ptr(2),
+                    RetryBackoff:       Duration(time.Second),
+                    StallThreshold:     3,
+                    FailureThreshold:   2,
+                    SuccessThreshold:   2,
+                    DialTimeout:        Duration(5 * time.Second),
+                    CallTimeout:        Duration(10 * time.Second),
+                    Transport:          globalTransport,
+                },
+                Retry: retryPolicy{retries: 2, backoff: time.Second},
+            },
+        },
+        {
+            name:     "endpoint only",
+            endpoint: overrides,
+            want: ResolvedEndpoint{
+                Endpoint: func() Endpoint {
+                    want := overrides
+                    want.Transport = endpointTransport
+                    return want
+                }(),
+                Retry: retryPolicy{retries: 0, backoff: 250 * time.Millisecond},
+            },
+        },
+        {
+            name:     "both set",
+            config:   global,
+            endpoint: overrides,
+            want: ResolvedEndpoint{
+                Endpoint: func() Endpoint {
+                    want := overrides
+                    want.Transport = mergedTransport
+                    return want
+                }(),
+                Retry: retryPolicy{retries: 0, backoff: 250 * time.Millisecond},
+            },
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := resolveEndpoint(tt.config, tt.endpoint)
+            if *got.InsecureSkipVerify != *tt.want.InsecureSkipVerify {
+                t.Errorf("InsecureSkipVerify = %v, want %v", *got.InsecureSkipVerify, *tt.want.InsecureSkipVerify)
+            }
+            if *got.Retries != *tt.want.Retries {
+                t.Errorf("Retries = %d, want %d", *got.Retries, *tt.want.Retries)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("resolveEndpoint() = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestResolveEndpointThresholds(t *testing.T) {
+    // Negative thresholds are rejected by validation, but they must never
+    // disable the debouncing
+    got := resolveEndpoint(Config{FailureThreshold: -1}, Endpoint{SuccessThreshold: -2})
+    if got.FailureThreshold != 1 || got.SuccessThreshold != 1 {
+        t.Errorf("thresholds = %d/%d, want 1/1", got.FailureThreshold, got.SuccessThreshold)
+    }
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "math/rand"
+    "net/http"
+    "time"
+
+    "github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+    // defaultRetryBackoff is the delay before the first retry when retry_backoff isn't set.
+    defaultRetryBackoff = 500 * time.Millisecond
+    // maxRetryBackoff caps the exponential growth of the retry delay.
+    maxRetryBackoff = 10 * time.Second
+)
+
+// retryPolicy describes how often a failed check is retried before the
+// endpoint is marked unhealthy.
+type retryPolicy struct {
+    retries int
+    backoff time.Duration
+}
+
+// delay returns the wait before retry n (starting at 1): exponential backoff
+// with jitter in the upper half of the window so endpoints don't retry in lockstep.
+func (p retryPolicy) delay(n int) time.Duration {
+    d := p.backoff << (n - 1)
+    if d <= 0 || d > maxRetryBackoff {
+        d = maxRetryBackoff
+    }
+    return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// withRetry calls attempt until it succeeds, fails with a non-retryable
+// error, runs out of retries, or the next delay would exceed ctx's deadline.
+// A rate-limited attempt waits at least as long as its Retry-After. onRetry is called before each wait.
+func withRetry(ctx context.Context, policy retryPolicy, attempt func() error, onRetry func(n int, err error, delay time.Duration)) error {
+    for n := 1; ; n++ {
+        err := attempt()
+        if err == nil || n > policy.retries || !isRetryable(err) {
+            return err
+        }
+
+        delay := policy.delay(n)
+        var limited *rateLimitedError
+        if errors.As(err, &limited) && limited.retryAfter > delay {
+            delay = limited.retryAfter
+        }
+        if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+            return err
+        }
+        onRetry(n, err, delay)
+
+        timer := time.NewTimer(delay)
+        select {
+        case <-timer.C:
+        case <-ctx.Done():
+            timer.Stop()
+            return err
+        }
+    }
+}
+
+// dialError marks a failure to connect to the endpoint as opposed to a
+// failure of the call itself.
+type dialError struct {
+    err error
+}
+
+func (e *dialError) Error() string { return e.err.Error() }
+func (e *dialError) Unwrap() error { return e.err }
+
+// isRetryable reports whether err is likely transient. JSON-RPC errors and
+// client-side HTTP errors won't go away by asking again.
+func isRetryable(err error) bool {
+    // Retrying would wait on the same exhausted budget
+    if errors.Is(err, errRateBudget) {
+        return false
+    }
+    // The endpoint will most likely send the same response again
+    if errors.Is(err, errResponseTooLarge) {
+        return false
+    }
+    var rpcErr rpc.Error
+    if errors.As(err, &rpcErr) {
+        return false
+    }
+    var httpErr rpc.HTTPError
+    if errors.As(err, &httpErr) {
+        return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= 500
+    }
+    return true
+}
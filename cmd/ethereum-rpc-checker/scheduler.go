@@ -0,0 +1,117 @@
+package main
+
+import (
+    "context"
+    "math/rand"
+    "sync"
+    "time"
+)
+
+// defaultMaxConcurrentChecks is used when max_concurrent_checks isn't set.
+const defaultMaxConcurrentChecks = 10
+
+// checkSlots is a semaphore bounding the number of checks running at once so
+// a large endpoint list doesn't open unbounded connections.
+var checkSlots = make(chan struct{}, defaultMaxConcurrentChecks)
+
+// setMaxConcurrentChecks resizes the check semaphore. It must only be called
+// while no checks are running.
+func setMaxConcurrentChecks(n int) {
+    if n <= 0 {
+        n = defaultMaxConcurrentChecks
+    }
+    checkSlots = make(chan struct{}, n)
+}
+
+func acquireCheckSlot(ctx context.Context) bool {
+    select {
+    case checkSlots <- struct{}{}:
+        return true
+    case <-ctx.Done():
+        return false
+    }
+}
+
+func releaseCheckSlot() {
+    <-checkSlots
+}
+
+// scheduler runs one check loop per endpoint of a config, plus the newHeads
+// subscriptions of subscribed endpoints, the push loop when a Pushgateway
+// is configured and the state file saver when a state file is. It is replaced by a fresh scheduler
+// whenever the config is reloaded.
+type scheduler struct {
+    cancel context.CancelFunc
+    wg     sync.WaitGroup
+}
+
+func startScheduler(parent context.Context, config Config) *scheduler {
+    ctx, cancel := context.WithCancel(parent)
+    s := &scheduler{cancel: cancel}
+    for i, endpoint := range config.Endpoints {
+        offset := startOffset(i, len(config.Endpoints), resolveEndpoint(config, endpoint).Interval.Duration())
+        s.wg.Add(1)
+        go func(endpoint Endpoint) {
+            defer s.wg.Done()
+            scheduleChecks(ctx, endpoint, config, offset)
+        }(endpoint)
+        if endpoint.Subscribe {
+            s.wg.Add(1)
+            go func(endpoint Endpoint) {
+                defer s.wg.Done()
+                followHeads(ctx, endpoint, config)
+            }(endpoint)
+        }
+    }
+    if config.StateFile != "" {
+        s.wg.Add(1)
+        go func() {
+            defer s.wg.Done()
+            saveStatePeriodically(ctx, config)
+        }()
+    }
+    if config.Pushgateway.URL != "" {
+        s.wg.Add(1)
+        go func() {
+            defer s.wg.Done()
+            schedulePushes(ctx, config)
+        }()
+    }
+    return s
+}
+
+// startOffset spreads the first scheduled check of n endpoints evenly over
+// the interval, so a sweep doesn't hit every endpoint at once.
+func startOffset(i, n int, interval time.Duration) time.Duration {
+    if n <= 1 {
+        return 0
+    }
+    return interval * time.Duration(i) / time.Duration(n)
+}
+
+// jitter randomizes interval by up to fraction of it in either direction.
+func jitter(interval time.Duration, fraction float64) time.Duration {
+    if fraction <= 0 {
+        return interval
+    }
+    spread := float64(interval) * fraction
+    return interval + time.Duration((rand.Float64()*2-1)*spread)
+}
+
+// stop cancels every check loop and waits for running checks to finish or
+// for ctx to expire, whichever comes first. It reports whether all loops exited.
+func (s *scheduler) stop(ctx context.Context) bool {
+    s.cancel()
+
+    done := make(chan struct{})
+    go func() {
+        s.wg.Wait()
+        close(done)
+    }()
+    select {
+    case <-done:
+        return true
+    case <-ctx.Done():
+        return false
+    }
+}
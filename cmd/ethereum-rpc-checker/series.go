@@ -0,0 +1,120 @@
+package main
+
+import (
+    "fmt"
+    "log/slog"
+    "strings"
+    "sync"
+
+    "github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultMaxSeries caps the labeled series when metrics.max_series isn't set.
+const defaultMaxSeries = 10000
+
+// seriesGuard caps the number of labeled series the checker creates, so a
+// large or misbehaving endpoint list can't blow up Prometheus. Series are
+// tracked per endpoint and forgotten with it.
+type seriesGuard struct {
+    mu     sync.Mutex
+    limit  int
+    count  int
+    series map[string]map[string]bool
+    warned bool
+}
+
+func newSeriesGuard(limit int) *seriesGuard {
+    if limit <= 0 {
+        limit = defaultMaxSeries
+    }
+    return &seriesGuard{limit: limit, series: make(map[string]map[string]bool)}
+}
+
+// allow reports whether the series of metric with the given labels, the
+// first being the endpoint, exists or may be created. The first refusal is
+// logged; later ones are dropped silently until a series is freed.
+func (g *seriesGuard) allow(metric string, labels ...string) bool {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+
+    endpoint := labels[0]
+    key := metric + "\xff" + strings.Join(labels[1:], "\xff")
+    if g.series[endpoint][key] {
+        return true
+    }
+    if g.count >= g.limit {
+        if !g.warned {
+            g.warned = true
+            slog.Warn(fmt.Sprintf("⚠️ Metric series limit of %d reached, not creating %s for %s", g.limit, metric, endpoint),
+                "metric", metric, "endpoint", endpoint, "max_series", g.limit)
+        }
+        return false
+    }
+    if g.series[endpoint] == nil {
+        g.series[endpoint] = make(map[string]bool)
+    }
+    g.series[endpoint][key] = true
+    g.count++
+    return true
+}
+
+// forget drops the tracked series of metric for an endpoint, or all of its
+// series when metric is empty, after they were deleted from their vectors.
+func (g *seriesGuard) forget(endpoint, metric string) {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+
+    for key := range g.series[endpoint] {
+        if metric == "" || strings.HasPrefix(key, metric+"\xff") {
+            delete(g.series[endpoint], key)
+            g.count--
+        }
+    }
+    if len(g.series[endpoint]) == 0 {
+        delete(g.series, endpoint)
+    }
+    if g.count < g.limit {
+        g.warned = false
+    }
+}
+
+// set, inc and observe update the series of vec, named metric, for the
+// given labels, the first being the endpoint, unless metric is disabled or
+// the series guard refuses to create it.
+func (m *Metrics) set(vec *prometheus.GaugeVec, metric string, value float64, labels ...string) {
+    if !m.disabled[metric] && m.series.allow(metric, labels...) {
+        vec.WithLabelValues(labels...).Set(value)
+    }
+}
+
+func (m *Metrics) inc(vec *prometheus.CounterVec, metric string, labels ...string) {
+    if !m.disabled[metric] && m.series.allow(metric, labels...) {
+        vec.WithLabelValues(labels...).Inc()
+    }
+}
+
+func (m *Metrics) observe(vec *prometheus.HistogramVec, metric string, value float64, labels ...string) {
+    if !m.disabled[metric] && m.series.allow(metric, labels...) {
+        vec.WithLabelValues(labels...).Observe(value)
+    }
+}
+
+// observeWithTrace is observe with traceID attached as an exemplar, so a
+// slow call in the histogram links to its trace. Exemplars are only exposed
+// to scrapers negotiating OpenMetrics.
+func (m *Metrics) observeWithTrace(vec *prometheus.HistogramVec, metric string, value float64, traceID string, labels ...string) {
+    if traceID == "" {
+        m.observe(vec, metric, value, labels...)
+        return
+    }
+    if !m.disabled[metric] && m.series.allow(metric, labels...) {
+        vec.WithLabelValues(labels...).(prometheus.ExemplarObserver).ObserveWithExemplar(value, prometheus.Labels{"trace_id": traceID})
+    }
+}
+
+// deleteEndpoint deletes the series of an endpoint from vec, named metric,
+// and frees them in the series guard.
+func (m *Metrics) deleteEndpoint(vec *prometheus.MetricVec, metric, endpoint string) {
+    vec.DeletePartialMatch(prometheus.Labels{"endpoint": endpoint})
+    m.series.forget(endpoint, metric)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+    "crypto/subtle"
+    "crypto/tls"
+    "fmt"
+    "log/slog"
+    "net"
+    "net/http"
+    "os"
+    "strings"
+
+    "github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// defaultMetricsPath is where metrics are served when prometheus.path isn't set.
+const defaultMetricsPath = "/metrics"
+
+// reservedPaths are served by the checker itself and can't be the metrics path.
+var reservedPaths = map[string]bool{"/status": true, "/healthz": true, "/readyz": true}
+
+// metricsPath returns the path metrics are served on.
+func metricsPath(config Config) string {
+    if config.Prometheus.Path != "" {
+        return config.Prometheus.Path
+    }
+    return defaultMetricsPath
+}
+
+// newMetricsHandler routes the metrics server. The metrics, /status and the
+// debug endpoints require basic auth when it is configured; the health probes
+// never do so orchestrators can reach them.
+func newMetricsHandler(config Config) http.Handler {
+    protect := func(h http.Handler) http.Handler { return h }
+    if auth := config.Prometheus.BasicAuth; auth.Username != "" {
+        protect = func(h http.Handler) http.Handler {
+            return requireBasicAuth(auth.Username, auth.Password, h)
+        }
+    }
+
+    mux := http.NewServeMux()
+    mux.Handle(metricsPath(config), protect(promhttp.HandlerFor(servedGatherer{}, promhttp.HandlerOpts{EnableOpenMetrics: true})))
+    mux.Handle("/status", protect(http.HandlerFunc(statusHandler)))
+    mux.HandleFunc("/healthz", healthzHandler)
+    mux.HandleFunc("/readyz", readyzHandler)
+    if config.Prometheus.DebugEndpoints {
+        debugMux := http.NewServeMux()
+        registerDebugHandlers(debugMux)
+        mux.Handle("/debug/", protect(debugMux))
+        slog.Warn(fmt.Sprintf("⚠️ pprof and expvar debug endpoints are enabled on %s/debug/", config.Prometheus.Address))
+    }
+    return mux
+}
+
+// requireBasicAuth rejects requests without the given credentials.
+func requireBasicAuth(username, password string, next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        user, pass, ok := r.BasicAuth()
+        userOK := subtle.ConstantTimeCompare([]byte(user), []byte(username)) == 1
+        passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(password)) == 1
+        if !ok || !userOK || !passOK {
+            w.Header().Set("WWW-Authenticate", `Basic realm="metrics"`)
+            http.Error(w, "unauthorized", http.StatusUnauthorized)
+            return
+        }
+        next.ServeHTTP(w, r)
+    })
+}
+
+// unixAddressPrefix marks a prometheus.address as the path of a Unix domain
+// socket, e.g. unix:/run/ethereum-rpc-checker.sock.
+const unixAddressPrefix = "unix:"
+
+// unixSocketPath returns the socket path of a unix: address.
+func unixSocketPath(address string) (string, bool) {
+    return strings.CutPrefix(address, unixAddressPrefix)
+}
+
+// validateMetricsAddress checks prometheus.address: a host:port, or unix:
+// followed by a socket path.
+func validateMetricsAddress(address string) error {
+    if path, ok := unixSocketPath(address); ok {
+        if path == "" {
+            return fmt.Errorf("invalid prometheus address %q: missing socket path", address)
+        }
+        return nil
+    }
+    if _, _, err := net.SplitHostPort(address); err != nil {
+        return fmt.Errorf("invalid prometheus address %q: %v", address, err)
+    }
+    return nil
+}
+
+// listenMetrics binds the metrics server's address. A Unix socket left
+// behind by a checker that didn't shut down cleanly is replaced, unless
+// another process still accepts connections on it. The socket file is
+// removed when the listener is closed on shutdown.
+func listenMetrics(address string) (net.Listener, error) {
+    path, ok := unixSocketPath(address)
+    if !ok {
+        return net.Listen("tcp", address)
+    }
+    if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+        if conn, err := net.Dial("unix", path); err == nil {
+            conn.Close()
+            return nil, fmt.Errorf("listen unix %s: address already in use", path)
+        }
+        if err := os.Remove(path); err != nil {
+            return nil, fmt.Errorf("error removing stale socket: %v", err)
+        }
+    }
+    return net.Listen("unix", path)
+}
+
+// serveMetrics runs the metrics server on listener until it is shut down,
+// over HTTPS when a certificate is configured.
+func serveMetrics(server *http.Server, listener net.Listener, config Config) error {
+    if config.Prometheus.CertFile != "" {
+        server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+        return server.ServeTLS(listener, config.Prometheus.CertFile, config.Prometheus.KeyFile)
+    }
+    return server.Serve(listener)
+}
+
+// validateMetricsServer checks the TLS and basic auth settings of the metrics server.
+func validateMetricsServer(config Config) []error {
+    var problems []error
+    p := config.Prometheus
+    if (p.CertFile == "") != (p.KeyFile == "") {
+        problems = append(problems, fmt.Errorf("prometheus: cert_file and key_file must be set together"))
+    } else if p.CertFile != "" {
+        if _, err := tls.LoadX509KeyPair(p.CertFile, p.KeyFile); err != nil {
+            problems = append(problems, fmt.Errorf("prometheus: error loading certificate: %v", err))
+        }
+    }
+    if (p.BasicAuth.Username == "") != (p.BasicAuth.Password == "") {
+        problems = append(problems, fmt.Errorf("prometheus: basic_auth needs both a username and a password"))
+    }
+    if p.Path != "" && !strings.HasPrefix(p.Path, "/") {
+        problems = append(problems, fmt.Errorf("prometheus: path %q must start with /", p.Path))
+    } else if reservedPaths[p.Path] || strings.HasPrefix(p.Path, "/debug/") {
+        problems = append(problems, fmt.Errorf("prometheus: path %q is already served by the checker", p.Path))
+    }
+    return problems
+}
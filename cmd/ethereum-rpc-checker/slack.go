@@ -0,0 +1,25 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "time"
+)
+
+// slackNotifier posts health changes to a Slack incoming webhook.
+type slackNotifier struct {
+    webhookURL string
+}
+
+func (slackNotifier) Name() string { return "Slack" }
+
+func (n slackNotifier) Notify(ctx context.Context, change StatusChange) error {
+    timestamp := change.Time.Format(time.RFC3339)
+    var text string
+    if change.Healthy {
+        text = fmt.Sprintf("✅ Endpoint *%s* is healthy again (%s)", change.Endpoint, timestamp)
+    } else {
+        text = fmt.Sprintf("🚨 Endpoint *%s* is unhealthy (%s): %v", change.Endpoint, timestamp, change.Err)
+    }
+    return postWebhook(ctx, n.webhookURL, map[string]string{"text": text})
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+    "sync"
+    "time"
+)
+
+// endpointState is what the checker remembers about an endpoint between checks.
+type endpointState struct {
+    lastBlock uint64
+    // unchanged counts consecutive successful checks where the height didn't advance.
+    unchanged int
+    // healthy is the reported health, once healthKnown is set: the outcome
+    // of the last check, debounced by the failure and success thresholds.
+    healthy     bool
+    healthKnown bool
+    // failures counts consecutive failed checks, reset by a successful one.
+    failures int
+    // successes counts consecutive successful checks, reset by a failed one.
+    successes int
+    // rateLimitedUntil is when the endpoint's last Retry-After delay ends.
+    rateLimitedUntil time.Time
+    // scheduled is when the endpoint was last scheduled, right before the
+    // sweep at startup or on reload, which the startup grace period counts from.
+    scheduled time.Time
+}
+
+// stateStore holds per-endpoint state. Checks run concurrently, so all
+// access goes through its methods.
+type stateStore struct {
+    mu        sync.Mutex
+    endpoints map[string]*endpointState
+}
+
+func newStateStore() *stateStore {
+    return &stateStore{endpoints: make(map[string]*endpointState)}
+}
+
+func (s *stateStore) get(name string) *endpointState {
+    state, ok := s.endpoints[name]
+    if !ok {
+        state = &endpointState{}
+        s.endpoints[name] = state
+    }
+    return state
+}
+
+// observeBlock records a block height and returns for how many consecutive
+// checks the height has not advanced. The first observation returns 0.
+func (s *stateStore) observeBlock(name string, block uint64) int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    state := s.get(name)
+    if block > state.lastBlock {
+        state.unchanged = 0
+    } else {
+        state.unchanged++
+    }
+    state.lastBlock = block
+    return state.unchanged
+}
+
+// observeHealth records the outcome of a check and returns the reported
+// health: a healthy endpoint is only reported unhealthy after
+// failureThreshold failed checks in a row, and an unhealthy one healthy again
+// after successThreshold successful checks in a row. The first observation
+// is reported as is. It also reports whether the reported health changed,
+// which is never the case for the first observation, and how many checks in
+// a row have now failed.
+func (s *stateStore) observeHealth(name string, healthy bool, failureThreshold, successThreshold int) (changed, reported bool, failures int) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    state := s.get(name)
+    if healthy {
+        state.failures = 0
+        state.successes++
+    } else {
+        state.failures++
+        state.successes = 0
+    }
+
+    reported = healthy
+    if state.healthKnown {
+        reported = state.healthy
+        if state.healthy && state.failures >= failureThreshold {
+            reported = false
+        } else if !state.healthy && state.successes >= successThreshold {
+            reported = true
+        }
+    }
+    changed = state.healthKnown && state.healthy != reported
+    state.healthy = reported
+    state.healthKnown = true
+    return changed, reported, state.failures
+}
+
+// rateLimit records that the endpoint asked to be left alone for delay.
+func (s *stateStore) rateLimit(name string, delay time.Duration) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.get(name).rateLimitedUntil = time.Now().Add(delay)
+}
+
+// rateLimitedFor returns how much of the endpoint's Retry-After delay is
+// left, 0 if it isn't rate limited.
+func (s *stateStore) rateLimitedFor(name string) time.Duration {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    state, ok := s.endpoints[name]
+    if !ok {
+        return 0
+    }
+    return max(time.Until(state.rateLimitedUntil), 0)
+}
+
+// schedule starts the startup grace period of every endpoint. It must be
+// called before the first sweep of a config so the sweep is covered too.
+func (s *stateStore) schedule(endpoints []Endpoint) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    now := time.Now()
+    for _, endpoint := range endpoints {
+        s.get(endpoint.Name).scheduled = now
+    }
+}
+
+// inGrace reports whether the endpoint was scheduled less than grace ago.
+func (s *stateStore) inGrace(name string, grace time.Duration) bool {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    state, ok := s.endpoints[name]
+    if !ok || state.scheduled.IsZero() {
+        return false
+    }
+    return time.Since(state.scheduled) < grace
+}
+
+// saved returns what the state file keeps about an endpoint, without its
+// last success which the status store knows. The last block is only tracked
+// with a stall threshold. ok is false for endpoints
+// without any state yet.
+func (s *stateStore) saved(name string) (saved savedEndpoint, ok bool) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    state, ok := s.endpoints[name]
+    if !ok {
+        return savedEndpoint{}, false
+    }
+    saved = savedEndpoint{LastBlock: state.lastBlock, ConsecutiveFailures: state.failures, ConsecutiveSuccesses: state.successes}
+    if state.healthKnown {
+        healthy := state.healthy
+        saved.Healthy = &healthy
+    }
+    return saved, true
+}
+
+// restore sets the state of an endpoint from the state file. A known health
+// keeps the first check after a restart from alerting again about an
+// unchanged outcome.
+func (s *stateStore) restore(name string, lastBlock uint64, healthy *bool, failures, successes int) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    state := s.get(name)
+    state.lastBlock = lastBlock
+    state.failures = failures
+    state.successes = successes
+    if healthy != nil {
+        state.healthy = *healthy
+        state.healthKnown = true
+    }
+}
+
+// remove forgets everything about an endpoint.
+func (s *stateStore) remove(name string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    delete(s.endpoints, name)
+}
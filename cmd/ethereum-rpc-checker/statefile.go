@@ -0,0 +1,131 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+    "log/slog"
+    "os"
+    "path/filepath"
+    "time"
+)
+
+// stateSaveInterval is how often the state file is written while running.
+const stateSaveInterval = 30 * time.Second
+
+// savedEndpoint is what the state file keeps about an endpoint.
+type savedEndpoint struct {
+    LastBlock            uint64     `json:"last_block,omitempty"`
+    Healthy              *bool      `json:"healthy,omitempty"`
+    ConsecutiveFailures  int        `json:"consecutive_failures"`
+    ConsecutiveSuccesses int        `json:"consecutive_successes,omitempty"`
+    LastSuccess          *time.Time `json:"last_success,omitempty"`
+}
+
+// savedState is the content of the state file.
+type savedState struct {
+    SavedAt   time.Time                `json:"saved_at"`
+    Endpoints map[string]savedEndpoint `json:"endpoints"`
+}
+
+// loadState restores what the state file knows about the configured
+// endpoints, so a restart doesn't reset failure counters or send duplicate
+// alerts. It is best effort: a missing or unreadable file starts fresh.
+func loadState(config Config) {
+    if config.StateFile == "" {
+        return
+    }
+    data, err := ioutil.ReadFile(config.StateFile)
+    if os.IsNotExist(err) {
+        slog.Info(fmt.Sprintf("💾 No state file at %s yet, starting fresh", config.StateFile), "state_file", config.StateFile)
+        return
+    }
+    var state savedState
+    if err == nil {
+        err = json.Unmarshal(data, &state)
+    }
+    if err != nil {
+        slog.Warn(fmt.Sprintf("⚠️ Ignoring state file %s, starting fresh: %v", config.StateFile, err), "state_file", config.StateFile, "error", err)
+        return
+    }
+
+    restored := 0
+    for _, endpoint := range config.Endpoints {
+        saved, ok := state.Endpoints[endpoint.Name]
+        if !ok {
+            continue
+        }
+        endpointStates.restore(endpoint.Name, saved.LastBlock, saved.Healthy, saved.ConsecutiveFailures, saved.ConsecutiveSuccesses)
+        metrics.set(metrics.consecutiveFailures, "rpc_consecutive_failures", float64(saved.ConsecutiveFailures), endpoint.Name)
+        if saved.LastSuccess != nil {
+            endpointStatuses.restore(endpoint, *saved.LastSuccess)
+            metrics.set(metrics.lastSuccess, "rpc_last_success_timestamp_seconds", float64(saved.LastSuccess.UnixNano())/1e9, endpoint.Name)
+        }
+        restored++
+    }
+    slog.Info(fmt.Sprintf("💾 Restored the state of %d endpoints from %s, saved at %s", restored, config.StateFile, state.SavedAt.Format(time.RFC3339)),
+        "state_file", config.StateFile, "endpoints", restored)
+}
+
+// saveState writes the state of the configured endpoints to the state file.
+// The file is replaced atomically so a crash can't leave it truncated.
+func saveState(config Config) {
+    if config.StateFile == "" {
+        return
+    }
+    state := savedState{SavedAt: time.Now().UTC(), Endpoints: make(map[string]savedEndpoint, len(config.Endpoints))}
+    for _, endpoint := range config.Endpoints {
+        saved, ok := endpointStates.saved(endpoint.Name)
+        if !ok {
+            continue
+        }
+        lastSuccess, lastBlock := endpointStatuses.saved(endpoint.Name)
+        saved.LastSuccess = lastSuccess
+        // Without a stall threshold the block is only known from the status
+        if saved.LastBlock == 0 {
+            saved.LastBlock = lastBlock
+        }
+        state.Endpoints[endpoint.Name] = saved
+    }
+
+    err := writeFileAtomic(config.StateFile, state)
+    if err != nil {
+        slog.Warn(fmt.Sprintf("⚠️ Failed to save state to %s: %v", config.StateFile, err), "state_file", config.StateFile, "error", err)
+    }
+}
+
+func writeFileAtomic(filename string, v interface{}) error {
+    data, err := json.MarshalIndent(v, "", "  ")
+    if err != nil {
+        return err
+    }
+    tmp, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".*")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name())
+    if _, err := tmp.Write(data); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        return err
+    }
+    return os.Rename(tmp.Name(), filename)
+}
+
+// saveStatePeriodically saves the state every stateSaveInterval until ctx
+// is cancelled.
+func saveStatePeriodically(ctx context.Context, config Config) {
+    ticker := time.NewTicker(stateSaveInterval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            saveState(config)
+        }
+    }
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+    "encoding/json"
+    "net/http"
+    "sort"
+    "sync"
+    "time"
+)
+
+// EndpointStatus is the outcome of the last check of an endpoint, as served by /status.
+type EndpointStatus struct {
+    Name           string     `json:"name"`
+    URL            string     `json:"url"`
+    Healthy        bool       `json:"healthy"`
+    LastCheckOK    bool       `json:"last_check_ok"`
+    Upstream       string     `json:"upstream,omitempty"`
+    BlockNumber    *uint64    `json:"block_number,omitempty"`
+    LatencySeconds float64    `json:"latency_seconds"`
+    Error          string     `json:"error,omitempty"`
+    ErrorCategory  string     `json:"error_category,omitempty"`
+    LastCheck      time.Time  `json:"last_check"`
+    LastSuccess    *time.Time `json:"last_success,omitempty"`
+}
+
+// statusStore keeps the last status of every endpoint for /status.
+type statusStore struct {
+    mu        sync.Mutex
+    endpoints map[string]EndpointStatus
+}
+
+func newStatusStore() *statusStore {
+    return &statusStore{endpoints: make(map[string]EndpointStatus)}
+}
+
+var endpointStatuses = newStatusStore()
+
+// record stores the outcome of a check. The URL is redacted as in the logs.
+func (s *statusStore) record(endpoint Endpoint, result CheckResult) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    status := EndpointStatus{
+        Name:           endpoint.Name,
+        URL:            maskSensitiveInfo(endpoint.URL),
+        Healthy:        result.Reported,
+        LastCheckOK:    result.Healthy,
+        LatencySeconds: result.Latency.Seconds(),
+        ErrorCategory:  result.ErrorCategory,
+        LastCheck:      time.Now().UTC(),
+        LastSuccess:    s.endpoints[endpoint.Name].LastSuccess,
+    }
+    if result.HasBlockNumber {
+        block := result.BlockNumber
+        status.BlockNumber = &block
+    }
+    if len(endpoint.FallbackURLs) > 0 {
+        status.Upstream = result.Upstream
+    }
+    if result.Err != nil {
+        status.Error = redactError(result.Err)
+    }
+    if result.Healthy {
+        status.LastSuccess = &status.LastCheck
+    }
+    s.endpoints[endpoint.Name] = status
+}
+
+// restore keeps the last success of an endpoint from the state file until
+// its first check.
+func (s *statusStore) restore(endpoint Endpoint, lastSuccess time.Time) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.endpoints[endpoint.Name] = EndpointStatus{Name: endpoint.Name, URL: maskSensitiveInfo(endpoint.URL), LastSuccess: &lastSuccess}
+}
+
+// saved returns when the endpoint was last healthy, nil if never, and the
+// block number of its last check, 0 if it didn't return one.
+func (s *statusStore) saved(name string) (lastSuccess *time.Time, lastBlock uint64) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    status := s.endpoints[name]
+    if status.BlockNumber != nil {
+        lastBlock = *status.BlockNumber
+    }
+    return status.LastSuccess, lastBlock
+}
+
+// remove forgets the status of an endpoint that is no longer configured.
+func (s *statusStore) remove(name string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    delete(s.endpoints, name)
+}
+
+// snapshot returns the statuses sorted by endpoint name.
+func (s *statusStore) snapshot() []EndpointStatus {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    statuses := make([]EndpointStatus, 0, len(s.endpoints))
+    for _, status := range s.endpoints {
+        // Restored from the state file but not checked since the restart
+        if status.LastCheck.IsZero() {
+            continue
+        }
+        statuses = append(statuses, status)
+    }
+    sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+    return statuses
+}
+
+// statusHandler serves the last status of every endpoint as JSON. It is read-only.
+func statusHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet && r.Method != http.MethodHead {
+        w.Header().Set("Allow", "GET, HEAD")
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(struct {
+        Endpoints []EndpointStatus `json:"endpoints"`
+    }{endpointStatuses.snapshot()})
+}
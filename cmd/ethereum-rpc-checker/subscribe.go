@@ -0,0 +1,148 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log/slog"
+    "strings"
+    "time"
+
+    "github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+    // maxSubscribeFailures is how many subscriptions in a row may fail
+    // before the block number is left to the interval checks.
+    maxSubscribeFailures = 5
+    // subscribeStablePeriod is how long a subscription must stay open for
+    // its drop not to count as a failure in a row.
+    subscribeStablePeriod = time.Minute
+    // subscribeFallbackPeriod is how long the interval checks are relied on
+    // before subscribing again.
+    subscribeFallbackPeriod = 10 * time.Minute
+    // resubscribeBackoff is the delay before the first resubscribe, doubled
+    // on each further failure.
+    resubscribeBackoff = time.Second
+)
+
+// subscriber is implemented by RPC clients connected over WebSocket or IPC,
+// which support eth_subscribe.
+type subscriber interface {
+    EthSubscribe(ctx context.Context, channel interface{}, args ...interface{}) (*rpc.ClientSubscription, error)
+}
+
+func (e *EthRPCClient) EthSubscribe(ctx context.Context, channel interface{}, args ...interface{}) (*rpc.ClientSubscription, error) {
+    return e.client.EthSubscribe(ctx, channel, args...)
+}
+
+// supportsSubscriptions reports whether rawURL uses a transport eth_subscribe
+// works over.
+func supportsSubscriptions(rawURL string) bool {
+    if _, ok := ipcPath(rawURL); ok {
+        return true
+    }
+    return strings.HasPrefix(rawURL, "ws://") || strings.HasPrefix(rawURL, "wss://")
+}
+
+// newHead is the part of a newHeads notification the checker reads.
+type newHead struct {
+    Number json.RawMessage `json:"number"`
+}
+
+// followHeads keeps a newHeads subscription open on the endpoint until ctx
+// is cancelled, updating the block number as heads arrive. A dropped
+// subscription is resubscribed with backoff; after maxSubscribeFailures
+// failed or short-lived subscriptions in a row the block number is left to
+// the interval checks for subscribeFallbackPeriod.
+func followHeads(ctx context.Context, endpoint Endpoint, config Config) {
+    endpoint = resolveEndpoint(config, endpoint).Endpoint
+    logEndpoint := endpointLogName(endpoint, config.Debug)
+    backoff := retryPolicy{backoff: resubscribeBackoff}
+    failures := 0
+    for {
+        open, err := subscribeHeads(ctx, endpoint, logEndpoint)
+        if ctx.Err() != nil {
+            return
+        }
+        metrics.set(metrics.headSubscribed, "head_subscription_active", 0, endpoint.Name)
+        if open >= subscribeStablePeriod {
+            failures = 0
+        }
+        failures++
+
+        wait := backoff.delay(failures)
+        if failures >= maxSubscribeFailures {
+            slog.Warn(fmt.Sprintf("📉 newHeads subscription on %s failed %d times in a row: %v, falling back to polling for %s", logEndpoint, failures, err, subscribeFallbackPeriod),
+                "endpoint", endpoint.Name, "error", err, "failures", failures)
+            wait = subscribeFallbackPeriod
+            failures = 0
+        } else {
+            slog.Warn(fmt.Sprintf("🔌 newHeads subscription on %s dropped: %v, resubscribing in %s", logEndpoint, err, wait.Round(time.Millisecond)),
+                "endpoint", endpoint.Name, "error", err, "failures", failures)
+        }
+
+        timer := time.NewTimer(wait)
+        select {
+        case <-ctx.Done():
+            timer.Stop()
+            return
+        case <-timer.C:
+        }
+    }
+}
+
+// subscribeHeads subscribes to newHeads on a dedicated connection and
+// records the heads until the subscription fails or ctx is cancelled. It
+// returns how long the subscription was open.
+func subscribeHeads(ctx context.Context, endpoint Endpoint, logEndpoint string) (time.Duration, error) {
+    client, err := rpcDial(ctx, endpoint)
+    if err != nil {
+        return 0, err
+    }
+    defer client.Close()
+    sub, ok := client.(subscriber)
+    if !ok {
+        return 0, fmt.Errorf("client doesn't support subscriptions")
+    }
+
+    heads := make(chan newHead)
+    subscription, err := sub.EthSubscribe(ctx, heads, "newHeads")
+    if err != nil {
+        return 0, err
+    }
+    defer subscription.Unsubscribe()
+    subscribed := time.Now()
+    slog.Info(fmt.Sprintf("📡 Subscribed to newHeads on %s", logEndpoint), "endpoint", endpoint.Name)
+    metrics.set(metrics.headSubscribed, "head_subscription_active", 1, endpoint.Name)
+
+    // The age of the last head is refreshed every second, so it keeps
+    // growing while no head arrives
+    ticker := time.NewTicker(time.Second)
+    defer ticker.Stop()
+    var lastHead time.Time
+    for {
+        select {
+        case <-ctx.Done():
+            return time.Since(subscribed), nil
+        case err := <-subscription.Err():
+            if err == nil {
+                err = fmt.Errorf("subscription closed")
+            }
+            return time.Since(subscribed), err
+        case head := <-heads:
+            number, err := decodeQuantity(head.Number)
+            if err != nil {
+                slog.Warn(fmt.Sprintf("⚠️ Cannot decode head from %s: %v", logEndpoint, err), "endpoint", endpoint.Name, "error", err)
+                continue
+            }
+            lastHead = time.Now()
+            metrics.set(metrics.blockNumber, "block_number", float64(number), endpoint.Name)
+            metrics.set(metrics.sinceLastHead, "seconds_since_last_head", 0, endpoint.Name)
+        case <-ticker.C:
+            if !lastHead.IsZero() {
+                metrics.set(metrics.sinceLastHead, "seconds_since_last_head", time.Since(lastHead).Seconds(), endpoint.Name)
+            }
+        }
+    }
+}
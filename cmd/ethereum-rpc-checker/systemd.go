@@ -0,0 +1,74 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "net"
+    "os"
+    "strconv"
+    "time"
+)
+
+// States sent to systemd with sdNotify.
+const (
+    sdReady    = "READY=1"
+    sdStopping = "STOPPING=1"
+    sdWatchdog = "WATCHDOG=1"
+)
+
+// sdNotify sends a state to systemd for Type=notify services. It does
+// nothing when not running under systemd, i.e. when NOTIFY_SOCKET isn't set.
+func sdNotify(state string) {
+    socket := os.Getenv("NOTIFY_SOCKET")
+    if socket == "" {
+        return
+    }
+    // A leading @ denotes an abstract socket
+    if socket[0] == '@' {
+        socket = "\x00" + socket[1:]
+    }
+    conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+    if err != nil {
+        slog.Warn(fmt.Sprintf("⚠️ Failed to notify systemd: %v", err), "error", err)
+        return
+    }
+    defer conn.Close()
+    if _, err := conn.Write([]byte(state)); err != nil {
+        slog.Warn(fmt.Sprintf("⚠️ Failed to notify systemd: %v", err), "error", err)
+    }
+}
+
+// watchdogInterval returns how often systemd expects a watchdog ping, half
+// of WATCHDOG_USEC as recommended, or 0 when the watchdog isn't enabled for
+// this process.
+func watchdogInterval() time.Duration {
+    usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+    if err != nil || usec <= 0 {
+        return 0
+    }
+    if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+        return 0
+    }
+    return time.Duration(usec) * time.Microsecond / 2
+}
+
+// runWatchdog pings the systemd watchdog until ctx is cancelled. It returns
+// right away when the watchdog isn't enabled.
+func runWatchdog(ctx context.Context) {
+    interval := watchdogInterval()
+    if interval <= 0 {
+        return
+    }
+    slog.Info(fmt.Sprintf("🐕 Pinging the systemd watchdog every %s", interval))
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            sdNotify(sdWatchdog)
+        }
+    }
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+    "crypto/tls"
+    "crypto/x509"
+    "fmt"
+    "io/ioutil"
+)
+
+// EndpointTLS configures TLS client settings for an endpoint, e.g. for nodes
+// behind an mTLS-terminating proxy.
+type EndpointTLS struct {
+    // CertFile and KeyFile are a PEM client certificate and key, set together.
+    CertFile string `yaml:"cert_file,omitempty"`
+    KeyFile  string `yaml:"key_file,omitempty"`
+    // CAFile is a PEM bundle trusted instead of the system roots.
+    CAFile string `yaml:"ca_file,omitempty"`
+}
+
+// load reads the client certificate and CA bundle. Both results are nil when not configured.
+func (t EndpointTLS) load() ([]tls.Certificate, *x509.CertPool, error) {
+    var certs []tls.Certificate
+    if t.CertFile != "" || t.KeyFile != "" {
+        if t.CertFile == "" || t.KeyFile == "" {
+            return nil, nil, fmt.Errorf("cert_file and key_file must be set together")
+        }
+        cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+        if err != nil {
+            return nil, nil, fmt.Errorf("error loading client certificate: %v", err)
+        }
+        certs = []tls.Certificate{cert}
+    }
+
+    var roots *x509.CertPool
+    if t.CAFile != "" {
+        pem, err := ioutil.ReadFile(t.CAFile)
+        if err != nil {
+            return nil, nil, fmt.Errorf("error reading CA bundle: %v", err)
+        }
+        roots = x509.NewCertPool()
+        if !roots.AppendCertsFromPEM(pem) {
+            return nil, nil, fmt.Errorf("no certificates found in CA bundle %s", t.CAFile)
+        }
+    }
+    return certs, roots, nil
+}
+
+// recordCertExpiry exports when the leaf certificate presented in a TLS
+// handshake with the endpoint expires. It runs from VerifyConnection, so
+// only https and wss endpoints get the metric, and it is updated whenever a
+// new connection is made, e.g. after a certificate was rotated.
+func recordCertExpiry(name string, cs tls.ConnectionState) {
+    if len(cs.PeerCertificates) == 0 {
+        return
+    }
+    metrics.set(metrics.certExpiry, "endpoint_cert_expiry_timestamp_seconds", float64(cs.PeerCertificates[0].NotAfter.Unix()), name)
+}
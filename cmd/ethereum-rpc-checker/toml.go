@@ -0,0 +1,30 @@
+package main
+
+import (
+    "path/filepath"
+    "strings"
+
+    "github.com/BurntSushi/toml"
+    "gopkg.in/yaml.v3"
+)
+
+// isTOML reports whether a config file is TOML by its extension. Any other
+// file is read as YAML.
+func isTOML(filename string) bool {
+    return strings.EqualFold(filepath.Ext(filename), ".toml")
+}
+
+// tomlToYAML converts a TOML config to YAML, so it is decoded into Config
+// with the same keys, duration formats and unknown field checks as a YAML
+// file.
+func tomlToYAML(data string) (string, error) {
+    var doc map[string]interface{}
+    if _, err := toml.Decode(data, &doc); err != nil {
+        return "", err
+    }
+    converted, err := yaml.Marshal(doc)
+    if err != nil {
+        return "", err
+    }
+    return string(converted), nil
+}
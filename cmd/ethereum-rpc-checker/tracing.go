@@ -0,0 +1,139 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "net/url"
+
+    "go.opentelemetry.io/otel"
+    "go.opentelemetry.io/otel/attribute"
+    "go.opentelemetry.io/otel/codes"
+    "go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+    "go.opentelemetry.io/otel/propagation"
+    "go.opentelemetry.io/otel/sdk/resource"
+    sdktrace "go.opentelemetry.io/otel/sdk/trace"
+    "go.opentelemetry.io/otel/trace"
+)
+
+// defaultTracingServiceName is the service.name of exported spans when
+// tracing.service_name isn't set.
+const defaultTracingServiceName = "ethereum-rpc-checker"
+
+// TracingConfig describes where spans of the checks are exported. Tracing is
+// off, and costs nothing, unless an endpoint is set.
+type TracingConfig struct {
+    // Endpoint is the OTLP/HTTP URL of the collector, e.g. http://otel-collector:4318
+    Endpoint    string `yaml:"endpoint"`
+    ServiceName string `yaml:"service_name"`
+    // Propagate sends the trace context to HTTP endpoints in the traceparent
+    // header, so providers that trace too can join the spans.
+    Propagate bool `yaml:"propagate"`
+}
+
+// tracer creates the check spans. It is a no-op until setupTracing installs
+// an exporting provider.
+var tracer = otel.Tracer("ethereum-rpc-checker")
+
+// validateTracing checks the tracing settings.
+func validateTracing(config TracingConfig) error {
+    if config.Endpoint == "" {
+        return nil
+    }
+    endpointURL, err := url.Parse(config.Endpoint)
+    if err != nil || (endpointURL.Scheme != "http" && endpointURL.Scheme != "https") || endpointURL.Host == "" {
+        return fmt.Errorf("invalid tracing endpoint %q", maskSensitiveInfo(config.Endpoint))
+    }
+    return nil
+}
+
+// setupTracing installs the OTLP exporter when tracing is configured. The
+// returned function flushes pending spans and must be called before exiting.
+func setupTracing(ctx context.Context, config TracingConfig) (func(context.Context), error) {
+    if config.Endpoint == "" {
+        return func(context.Context) {}, nil
+    }
+    exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(config.Endpoint))
+    if err != nil {
+        return nil, fmt.Errorf("error creating trace exporter: %v", err)
+    }
+    serviceName := config.ServiceName
+    if serviceName == "" {
+        serviceName = defaultTracingServiceName
+    }
+    provider := sdktrace.NewTracerProvider(
+        sdktrace.WithBatcher(exporter),
+        sdktrace.WithResource(resource.NewSchemaless(
+            attribute.String("service.name", serviceName),
+            attribute.String("service.version", version),
+        )),
+    )
+    otel.SetTracerProvider(provider)
+    if config.Propagate {
+        otel.SetTextMapPropagator(propagation.TraceContext{})
+    }
+    slog.Info(fmt.Sprintf("🧵 Exporting traces to %s", maskSensitiveInfo(config.Endpoint)), "endpoint", maskSensitiveInfo(config.Endpoint))
+
+    return func(ctx context.Context) {
+        if err := provider.Shutdown(ctx); err != nil {
+            slog.Warn(fmt.Sprintf("⚠️ Failed to flush traces: %v", err), "error", err)
+        }
+    }, nil
+}
+
+// startCheckSpan starts the span of a check of endpoint with method.
+func startCheckSpan(ctx context.Context, endpoint Endpoint, method string) (context.Context, trace.Span) {
+    return tracer.Start(ctx, "check "+method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+        attribute.String("endpoint", endpoint.Name),
+        attribute.String("rpc.method", method),
+    ))
+}
+
+// endCheckSpan records the outcome of a check on its span and ends it.
+func endCheckSpan(span trace.Span, result CheckResult) {
+    if result.HasBlockNumber {
+        span.SetAttributes(attribute.Int64("block_number", int64(result.BlockNumber)))
+    }
+    span.SetAttributes(attribute.Bool("healthy", result.Healthy))
+    if result.Err != nil {
+        // Spans leave the process, so the URLs in the error are redacted
+        message := redactError(result.Err)
+        span.RecordError(errors.New(message))
+        span.SetStatus(codes.Error, message)
+        if result.ErrorCategory != "" {
+            span.SetAttributes(attribute.String("error.category", result.ErrorCategory))
+        }
+    }
+    span.End()
+}
+
+// spanTraceID returns the trace ID of span, or "" when tracing is off and
+// the span isn't recorded.
+func spanTraceID(span trace.Span) string {
+    if sc := span.SpanContext(); sc.HasTraceID() {
+        return sc.TraceID().String()
+    }
+    return ""
+}
+
+// tracePropagationTransport injects the trace context of a request into its
+// headers. The default propagator injects nothing, so unless
+// tracing.propagate is set requests go out unchanged.
+type tracePropagationTransport struct {
+    base http.RoundTripper
+}
+
+func (t *tracePropagationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    carrier := propagation.HeaderCarrier{}
+    otel.GetTextMapPropagator().Inject(req.Context(), carrier)
+    if len(carrier) > 0 {
+        // A RoundTripper must not modify the request it was given
+        req = req.Clone(req.Context())
+        for key, values := range carrier {
+            req.Header[key] = values
+        }
+    }
+    return t.base.RoundTrip(req)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "time"
+)
+
+// Defaults of the HTTP transport settings.
+const (
+    defaultMaxIdleConnsPerHost = 100
+    defaultIdleConnTimeout     = 90 * time.Second
+    defaultTLSHandshakeTimeout = 10 * time.Second
+    defaultKeepAlive           = 30 * time.Second
+    defaultMaxResponseSize     = 5 << 20
+)
+
+// TransportConfig tunes connection reuse of HTTP endpoints. Zero values
+// inherit the global setting, then the default.
+type TransportConfig struct {
+    MaxIdleConnsPerHost int      `yaml:"max_idle_conns_per_host,omitempty"`
+    IdleConnTimeout     Duration `yaml:"idle_conn_timeout,omitempty"`
+    TLSHandshakeTimeout Duration `yaml:"tls_handshake_timeout,omitempty"`
+    KeepAlive           Duration `yaml:"keep_alive,omitempty"`
+    // MaxResponseSize caps the size of a response in bytes, so a hostile
+    // endpoint can't exhaust memory
+    MaxResponseSize int64 `yaml:"max_response_size,omitempty"`
+}
+
+// validate checks that no transport setting is negative.
+func (t TransportConfig) validate() error {
+    if t.MaxIdleConnsPerHost < 0 || t.IdleConnTimeout < 0 || t.TLSHandshakeTimeout < 0 || t.KeepAlive < 0 || t.MaxResponseSize < 0 {
+        return fmt.Errorf("transport settings cannot be negative")
+    }
+    return nil
+}
+
+// errResponseTooLarge is returned when a response exceeds max_response_size.
+var errResponseTooLarge = errors.New("response too large")
+
+// responseLimitTransport rejects responses larger than limit bytes, either
+// up front from their Content-Length or once reading goes past the limit.
+type responseLimitTransport struct {
+    base  http.RoundTripper
+    limit int64
+}
+
+func (t *responseLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    resp, err := t.base.RoundTrip(req)
+    if err != nil {
+        return nil, err
+    }
+    if resp.ContentLength > t.limit {
+        resp.Body.Close()
+        return nil, fmt.Errorf("%w: %d bytes, the limit is %d", errResponseTooLarge, resp.ContentLength, t.limit)
+    }
+    resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.limit, limit: t.limit}
+    return resp, nil
+}
+
+// limitedBody fails reads past limit bytes instead of silently truncating
+// the response like io.LimitReader would.
+type limitedBody struct {
+    io.ReadCloser
+    remaining int64
+    limit     int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+    if b.remaining <= 0 {
+        // Only an extra byte tells a response of exactly limit bytes apart
+        var probe [1]byte
+        if n, err := b.ReadCloser.Read(probe[:]); n == 0 {
+            return 0, err
+        }
+        return 0, fmt.Errorf("%w: more than %d bytes", errResponseTooLarge, b.limit)
+    }
+    if int64(len(p)) > b.remaining {
+        p = p[:b.remaining]
+    }
+    n, err := b.ReadCloser.Read(p)
+    b.remaining -= int64(n)
+    return n, err
+}
+
+// hostHeaderTransport sends requests with another Host than the URL's, e.g.
+// for nodes behind an ingress routing on it. net/http ignores a Host set in
+// the headers, so it must be set on the request.
+type hostHeaderTransport struct {
+    base http.RoundTripper
+    host string
+}
+
+func (t *hostHeaderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    // A RoundTripper must not modify the request it was given
+    req = req.Clone(req.Context())
+    req.Host = t.host
+    return t.base.RoundTrip(req)
+}
+
+// proxySchemes are the proxy URL schemes both the HTTP and the WebSocket
+// transports support.
+var proxySchemes = map[string]bool{"http": true, "socks5": true}
+
+// endpointProxy returns the proxy function of an endpoint: its explicit proxy
+// URL, or else the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+func endpointProxy(endpoint Endpoint) (func(*http.Request) (*url.URL, error), error) {
+    if endpoint.Proxy == "" {
+        return http.ProxyFromEnvironment, nil
+    }
+    proxyURL, err := url.Parse(endpoint.Proxy)
+    if err != nil {
+        return nil, fmt.Errorf("invalid proxy URL")
+    }
+    return http.ProxyURL(proxyURL), nil
+}
+
+// validateProxy checks an endpoint's proxy URL. Errors don't include the URL
+// itself, which may carry credentials.
+func validateProxy(proxy string) error {
+    if proxy == "" {
+        return nil
+    }
+    proxyURL, err := url.Parse(proxy)
+    if err != nil || proxyURL.Host == "" {
+        return fmt.Errorf("invalid proxy URL")
+    }
+    if !proxySchemes[proxyURL.Scheme] {
+        return fmt.Errorf("unsupported proxy scheme %q, expected http or socks5", proxyURL.Scheme)
+    }
+    return nil
+}
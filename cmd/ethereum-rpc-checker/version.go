@@ -0,0 +1,32 @@
+package main
+
+import (
+    "fmt"
+    "runtime"
+    "runtime/debug"
+)
+
+// Set at build time with -ldflags "-X main.version=... -X main.commit=... -X main.date=...".
+var (
+    version = "dev"
+    commit  = "unknown"
+    date    = "unknown"
+)
+
+func init() {
+    // Builds that pass an empty value, e.g. a Docker build without VERSION, still get a label
+    if version == "" {
+        version = "dev"
+    }
+    // go install module@version records the version in the build info
+    if info, ok := debug.ReadBuildInfo(); ok && version == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+        version = info.Main.Version
+    }
+    if commit == "" {
+        commit = "unknown"
+    }
+}
+
+func printVersion() {
+    fmt.Printf("ethereum-rpc-checker %s (commit %s, built %s, %s)\n", version, commit, date, runtime.Version())
+}